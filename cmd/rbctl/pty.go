@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"redbull/internal/rbhttp"
+	"redbull/internal/rbpty"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/term"
+)
+
+// detachKey is Ctrl-], as in telnet. It leaves the terminal running on the
+// beacon so it can be attached to again.
+const detachKey = 0x1d
+
+// OpenPty asks the beacon to start a shell under a pty of the given size.
+func (c *apiClient) OpenPty(sessionID, shell string, cols, rows int) (rbpty.Terminal, error) {
+	terminal, err := rbhttp.Post[rbpty.Terminal](c.client, fmt.Sprintf("%s/sessions/%s/ptys", c.server, url.PathEscape(sessionID)), rbhttp.OpenPtyRequest{
+		Shell:    shell,
+		Cols:     cols,
+		Rows:     rows,
+		Operator: c.operator,
+	})
+	if err != nil {
+		return rbpty.Terminal{}, fmt.Errorf("failed to open pty: %w", err)
+	}
+	return *terminal, nil
+}
+
+// openPty opens a terminal on the session's beacon and attaches to it.
+func openPty(client *apiClient, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("usage: rbctl pty <session> [shell]")
+	}
+	sess, err := client.ResolveSession(args[0])
+	if err != nil {
+		return err
+	}
+	shell := ""
+	if len(args) == 2 {
+		shell = args[1]
+	}
+
+	cols, rows, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		cols, rows = 80, 24
+	}
+	terminal, err := client.OpenPty(sess.ID, shell, cols, rows)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "opening pty %s, waiting for the beacon (sleep %ds)...\r\n", terminal.ID, sess.SleepTime)
+	return attachPty(client, terminal.ID)
+}
+
+// attachPty relays the local terminal to a beacon terminal until it exits or
+// the operator detaches with Ctrl-].
+func attachPty(client *apiClient, id string) error {
+	wsUrl := "ws" + strings.TrimPrefix(client.server, "http") + "/ptys/" + url.PathEscape(id) + "/ws"
+	conn, resp, err := websocket.DefaultDialer.Dial(wsUrl, nil)
+	if err != nil {
+		if resp != nil {
+			defer resp.Body.Close()
+			return fmt.Errorf("failed to attach: %w", responseError(resp))
+		}
+		return fmt.Errorf("failed to attach: %w", err)
+	}
+	defer conn.Close()
+	out := &ptyWriter{conn: conn}
+
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return fmt.Errorf("failed to set up terminal: %w", err)
+		}
+		defer term.Restore(fd, state)
+	}
+
+	done := make(chan error, 2)
+	go func() {
+		done <- relayPtyOutput(conn)
+	}()
+	go sendPtyInput(out, done)
+	go watchPtySize(out)
+
+	err = <-done
+	if errors.Is(err, errDetached) {
+		fmt.Fprintf(os.Stderr, "\r\ndetached, reattach with: rbctl attach %s\r\n", id)
+		return nil
+	}
+	return err
+}
+
+var errDetached = errors.New("detached")
+
+// ptyWriter lets keystrokes and resizes share the connection, which allows
+// only one writer at a time.
+type ptyWriter struct {
+	conn *websocket.Conn
+	sync.Mutex
+}
+
+func (w *ptyWriter) send(message rbhttp.PtyMessage) error {
+	w.Lock()
+	defer w.Unlock()
+	return w.conn.WriteJSON(message)
+}
+
+// relayPtyOutput prints the terminal's output until it exits.
+func relayPtyOutput(conn *websocket.Conn) error {
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				return nil
+			}
+			return fmt.Errorf("connection lost: %w", err)
+		}
+		if messageType == websocket.BinaryMessage {
+			os.Stdout.Write(data)
+			continue
+		}
+
+		var message rbhttp.PtyMessage
+		if err := json.Unmarshal(data, &message); err == nil && message.Type == "exit" {
+			if message.Error != "" {
+				return fmt.Errorf("pty closed: %s", strings.TrimSpace(message.Error))
+			}
+			fmt.Fprintf(os.Stderr, "\r\npty exited with code %d\r\n", message.ExitCode)
+			return nil
+		}
+	}
+}
+
+// sendPtyInput sends keystrokes as they are typed. Ctrl-C goes to the remote
+// terminal like any other key, since the local one is in raw mode.
+func sendPtyInput(out *ptyWriter, done chan<- error) {
+	buf := make([]byte, 1024)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if n > 0 {
+			if i := strings.IndexByte(string(buf[:n]), detachKey); i >= 0 {
+				if i > 0 {
+					out.send(rbhttp.PtyMessage{Type: "input", Data: string(buf[:i])})
+				}
+				done <- errDetached
+				return
+			}
+			if err := out.send(rbhttp.PtyMessage{Type: "input", Data: string(buf[:n])}); err != nil {
+				return
+			}
+		}
+		if err != nil {
+			// Input ran out, but output may still be on its way
+			return
+		}
+	}
+}
+
+// watchPtySize passes on changes to the local window size. Polling works the
+// same everywhere, unlike SIGWINCH.
+func watchPtySize(out *ptyWriter) {
+	fd := int(os.Stdout.Fd())
+	cols, rows, _ := term.GetSize(fd)
+	for range time.Tick(500 * time.Millisecond) {
+		c, r, err := term.GetSize(fd)
+		if err != nil || c == cols && r == rows {
+			continue
+		}
+		cols, rows = c, r
+		if err := out.send(rbhttp.PtyMessage{Type: "resize", Cols: cols, Rows: rows}); err != nil {
+			return
+		}
+	}
+}
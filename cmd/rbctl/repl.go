@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"redbull/internal/rbhttp"
+	"redbull/internal/rbusage"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const maxHistory = 500
+
+// replCommands run in rbctl itself rather than being queued for the beacon.
+var replCommands = map[string]string{
+	"exit":   "Leave the session: exit",
+	"push":   "Upload a local file to the beacon: push <local-file> [remote-name]",
+	"pull":   "Download a file from the beacon: pull <remote-file> [local-file]",
+	"script": "Queue every command in a local file: script <file>",
+	"cancel": "Cancel a queued or running task: cancel <task-id>",
+	"help":   "List commands: help",
+}
+
+// session is an interactive session with one beacon.
+type session struct {
+	client *apiClient
+	id     string
+	out    io.Writer
+}
+
+// interact opens a REPL for the session. Lines are queued as tasks, and
+// results for the session are printed as they arrive.
+func interact(client *apiClient, sess rbhttp.SessionOverview) error {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		// Piped input is treated as a script
+		return runScript(context.Background(), client, sess.ID, os.Stdin, os.Stdout)
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("failed to set up terminal: %w", err)
+	}
+	defer term.Restore(fd, state)
+
+	terminal := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, fmt.Sprintf("%s@%s> ", sess.Username, sess.Hostname))
+	terminal.AutoCompleteCallback = completeCommand(commandNames())
+
+	history := loadHistory(terminal)
+	if history != nil {
+		defer history.Close()
+	}
+
+	s := &session{client: client, id: sess.ID, out: terminal}
+
+	// Only show results that arrive from now on
+	since := time.Now()
+	if latest, err := client.Responses(url.Values{"session": {sess.ID}}); err == nil && len(latest) > 0 {
+		since = latest[len(latest)-1].Time
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		if err := client.Follow(ctx, url.Values{"session": {sess.ID}}, since, terminal); err != nil {
+			fmt.Fprintf(terminal, "stopped following results: %v\n", err)
+		}
+	}()
+
+	fmt.Fprintf(terminal, "Interacting with %s (%s). Type help for commands.\n", sess.Hostname, sess.ID)
+	for {
+		line, err := terminal.ReadLine()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if history != nil {
+			fmt.Fprintln(history, line)
+		}
+		if line == "exit" {
+			return nil
+		}
+		if err := s.handle(ctx, line); err != nil {
+			fmt.Fprintf(terminal, "error: %v\n", err)
+		}
+	}
+}
+
+func (s *session) handle(ctx context.Context, line string) error {
+	name, args, _ := strings.Cut(line, " ")
+	args = strings.TrimSpace(args)
+
+	switch name {
+	case "help":
+		s.help()
+		return nil
+	case "push":
+		return s.push(strings.Fields(args))
+	case "pull":
+		return s.pull(ctx, strings.Fields(args))
+	case "cancel":
+		if args == "" {
+			return errors.New(replCommands["cancel"])
+		}
+		taskID, err := s.client.ResolveTask(s.id, args)
+		if err != nil {
+			return err
+		}
+		state, err := s.client.CancelTask(s.id, taskID)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(s.out, "cancelled %s task %s\n", state, shortID(taskID))
+		return nil
+	case "script":
+		file, err := os.Open(args)
+		if err != nil {
+			return fmt.Errorf("failed to open script: %w", err)
+		}
+		defer file.Close()
+		return queueScript(s.client, s.id, file, s.out)
+	}
+
+	taskID, err := s.client.Queue(s.id, line)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "queued task %s\n", shortID(taskID))
+	return nil
+}
+
+func (s *session) help() {
+	for _, name := range commandNames() {
+		help, ok := replCommands[name]
+		if !ok {
+			help = rbusage.Commands[name]
+		}
+		fmt.Fprintf(s.out, "  %-10s %s\n", name, help)
+	}
+}
+
+// push stages a local file on the server and queues the beacon to fetch it.
+func (s *session) push(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New(replCommands["push"])
+	}
+
+	localPath := args[0]
+	remoteName := filepath.Base(localPath)
+	if len(args) == 2 {
+		remoteName = args[1]
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	stagedName := filepath.Base(localPath)
+	if err := s.client.StageUpload(stagedName, file); err != nil {
+		return err
+	}
+	taskID, err := s.client.Queue(s.id, fmt.Sprintf("upload %s %s", stagedName, remoteName))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "queued upload of %s as task %s\n", localPath, shortID(taskID))
+	return nil
+}
+
+// pull has the beacon download a file, waits for it to reach the server and
+// saves it locally.
+func (s *session) pull(ctx context.Context, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New(replCommands["pull"])
+	}
+	return pullFile(ctx, s.client, s.id, args, s.out)
+}
+
+func pullFile(ctx context.Context, client *apiClient, sessionID string, args []string, out io.Writer) error {
+	remotePath := args[0]
+	localPath := filepath.Base(remotePath)
+	if len(args) == 2 {
+		localPath = args[1]
+	}
+
+	taskID, err := client.Queue(sessionID, "download "+remotePath)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "waiting for task %s to download %s\n", shortID(taskID), remotePath)
+
+	resp, err := client.Wait(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if resp.Failed {
+		return errors.New(strings.TrimSpace(resp.Stderr))
+	}
+
+	// The download command reports the name the server stored the file under last
+	fields := strings.Fields(resp.Stdout)
+	if len(fields) == 0 {
+		return errors.New("the beacon did not report where the file was stored")
+	}
+	storedName := fields[len(fields)-1]
+
+	file, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	if err := client.FetchDownload(storedName, file); err != nil {
+		os.Remove(localPath)
+		return err
+	}
+	fmt.Fprintf(out, "saved %s to %s\n", remotePath, localPath)
+	return nil
+}
+
+// scriptLines returns the commands in a script, skipping blank lines and
+// lines starting with #.
+func scriptLines(r io.Reader) ([]string, error) {
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+	return lines, nil
+}
+
+// queueScript queues every command in the script without waiting for results.
+// The beacon runs them in order.
+func queueScript(client *apiClient, sessionID string, r io.Reader, out io.Writer) error {
+	lines, err := scriptLines(r)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		taskID, err := client.Queue(sessionID, line)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "queued task %s: %s\n", shortID(taskID), line)
+	}
+	return nil
+}
+
+// runScript queues the commands in the script one at a time, printing each
+// result before queueing the next.
+func runScript(ctx context.Context, client *apiClient, sessionID string, r io.Reader, out io.Writer) error {
+	lines, err := scriptLines(r)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		taskID, err := client.Queue(sessionID, line)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Wait(ctx, taskID)
+		if err != nil {
+			return err
+		}
+		printResponse(out, resp)
+	}
+	return nil
+}
+
+// commandNames lists the beacon's commands along with rbctl's own, sorted.
+func commandNames() []string {
+	names := make([]string, 0)
+	for name := range rbusage.Commands {
+		names = append(names, name)
+	}
+	for name := range replCommands {
+		if _, ok := rbusage.Commands[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// completeCommand completes the command name at the start of the line on tab,
+// as far as the candidates agree.
+func completeCommand(names []string) func(line string, pos int, key rune) (string, int, bool) {
+	return func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' || strings.Contains(line[:pos], " ") {
+			return "", 0, false
+		}
+
+		prefix := line[:pos]
+		matches := make([]string, 0)
+		for _, name := range names {
+			if strings.HasPrefix(name, prefix) {
+				matches = append(matches, name)
+			}
+		}
+		if len(matches) == 0 {
+			return "", 0, false
+		}
+
+		completed := matches[0]
+		for _, match := range matches[1:] {
+			for !strings.HasPrefix(match, completed) {
+				completed = completed[:len(completed)-1]
+			}
+		}
+		if len(matches) == 1 {
+			completed += " "
+		}
+		return completed + line[pos:], len(completed), true
+	}
+}
+
+// loadHistory fills the terminal's history from ~/.redbull/rbctl_history and
+// returns the file to append new lines to, or nil if it cannot be opened.
+func loadHistory(terminal *term.Terminal) *os.File {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	path := filepath.Join(homeDir, ".redbull", "rbctl_history")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil
+	}
+
+	if file, err := os.Open(path); err == nil {
+		lines := make([]string, 0)
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		file.Close()
+		for _, line := range lines[max(len(lines)-maxHistory, 0):] {
+			terminal.History.Add(line)
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil
+	}
+	return file
+}
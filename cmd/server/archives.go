@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"redbull/internal/rbarchive"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+)
+
+var manifestStoragePath string
+
+func manifestPath(filename string) string {
+	return filepath.Join(manifestStoragePath, filename+".json")
+}
+
+// catalogArchive writes a manifest for a downloaded file if it is an archive,
+// such as one made by download -r. Other files are left alone.
+func catalogArchive(filename string) error {
+	filePath := filepath.Join(fileStoragePath, filename)
+	format, err := rbarchive.Detect(filePath)
+	if err != nil {
+		return err
+	}
+	if format == "" {
+		return nil
+	}
+
+	manifest, err := rbarchive.Catalog(filePath, format)
+	if err != nil {
+		// Plain gzip files look like archives until they are read
+		zap.L().Debug("catalogArchive - not a readable archive", zap.Error(err), zap.String("file", filename))
+		return nil
+	}
+	manifest.Archive = filename
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(manifestPath(filename), data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	zap.L().Info("Catalogued archive", zap.String("file", filename), zap.String("format", format), zap.Int("files", manifest.Files))
+	return nil
+}
+
+// loadManifest returns the manifest of a downloaded archive, or
+// os.ErrNotExist if the file is not a catalogued archive.
+func loadManifest(filename string) (rbarchive.Manifest, error) {
+	var manifest rbarchive.Manifest
+	data, err := os.ReadFile(manifestPath(filename))
+	if err != nil {
+		return manifest, err
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// manifestFromURL loads the manifest for the file named in the URL, writing
+// an error response if there is none.
+func manifestFromURL(w http.ResponseWriter, r *http.Request) (rbarchive.Manifest, bool) {
+	filename := chi.URLParam(r, "filename")
+	if filename == "" || filepath.Base(filename) != filename {
+		errorResponse(w, r, 400, errInvalidFilename.Error())
+		return rbarchive.Manifest{}, false
+	}
+
+	manifest, err := loadManifest(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		errorResponse(w, r, 404, "no archive manifest for file")
+		return manifest, false
+	}
+	if err != nil {
+		zap.L().Error("manifestFromURL - load", zap.Error(err), zap.String("file", filename))
+		errorResponse(w, r, 500, "failed to load manifest")
+		return manifest, false
+	}
+	return manifest, true
+}
+
+func fetchManifest(w http.ResponseWriter, r *http.Request) {
+	manifest, ok := manifestFromURL(w, r)
+	if !ok {
+		return
+	}
+	render.JSON(w, r, manifest)
+}
+
+// fetchArchiveEntry serves a single file out of a downloaded archive, named by
+// the path query parameter.
+func fetchArchiveEntry(w http.ResponseWriter, r *http.Request) {
+	manifest, ok := manifestFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	name := r.URL.Query().Get("path")
+	var entry *rbarchive.Entry
+	for i := range manifest.Entries {
+		if manifest.Entries[i].Path == name {
+			entry = &manifest.Entries[i]
+			break
+		}
+	}
+	if entry == nil {
+		errorResponse(w, r, 404, rbarchive.ErrEntryNotFound.Error())
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(entry.Path)))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", entry.Size))
+	if err := rbarchive.Extract(filepath.Join(fileStoragePath, manifest.Archive), manifest.Format, entry.Path, w); err != nil {
+		zap.L().Error("fetchArchiveEntry - extract", zap.Error(err), zap.String("file", manifest.Archive), zap.String("path", entry.Path))
+	}
+}
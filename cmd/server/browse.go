@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"redbull/internal/rbarchive"
+	"redbull/internal/rbbrowse"
+	"redbull/internal/rbhttp"
+	"redbull/internal/rbsession"
+	"strconv"
+
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+)
+
+var browser = rbbrowse.NewBrowser()
+
+// fetchRemoteDirs lists every directory cached for the session.
+func fetchRemoteDirs(w http.ResponseWriter, r *http.Request) {
+	sess, ok := sessionFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	tree, ok := browser.Tree(sess.ID)
+	if !ok {
+		render.JSON(w, r, make([]rbbrowse.DirSummary, 0))
+		return
+	}
+	render.JSON(w, r, tree.Dirs())
+}
+
+// fetchRemoteNode returns the cached view of the path query parameter, with
+// depth levels of children (1 by default).
+func fetchRemoteNode(w http.ResponseWriter, r *http.Request) {
+	sess, ok := sessionFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	depth := 1
+	if d := r.URL.Query().Get("depth"); d != "" {
+		parsed, err := strconv.Atoi(d)
+		if err != nil || parsed < 0 {
+			errorResponse(w, r, 400, "invalid depth")
+			return
+		}
+		depth = parsed
+	}
+
+	node, ok := remoteNode(w, r, sess, r.URL.Query().Get("path"), depth)
+	if !ok {
+		return
+	}
+	render.JSON(w, r, node)
+}
+
+func remoteNode(w http.ResponseWriter, r *http.Request, sess *rbsession.Session, path string, depth int) (rbbrowse.Node, bool) {
+	tree, ok := browser.Tree(sess.ID)
+	if !ok {
+		errorResponse(w, r, 404, rbbrowse.ErrPathNotFound.Error())
+		return rbbrowse.Node{}, false
+	}
+	node, err := tree.Node(path, depth)
+	if errors.Is(err, rbbrowse.ErrPathNotFound) {
+		errorResponse(w, r, 404, err.Error())
+		return node, false
+	}
+	return node, true
+}
+
+// refreshRemotePath queues an ls of the path, which replaces the cached
+// listing when the beacon reports back.
+func refreshRemotePath(w http.ResponseWriter, r *http.Request) {
+	sess, ok := sessionFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var refreshRequest rbhttp.RefreshPathRequest
+	if err := render.Bind(r, &refreshRequest); err != nil {
+		zap.L().Error("refreshRemotePath - bind", zap.Error(err))
+		errorResponse(w, r, 400, err.Error())
+		return
+	}
+
+	path := rbbrowse.Clean(refreshRequest.Path)
+	task := rbsession.NewTask(rbbrowse.RefreshCommand(path, refreshRequest.Depth), false, 0)
+	task.Operator = refreshRequest.Operator
+	browser.Refreshing(task.ID, sess.ID, path, refreshRequest.Depth)
+	sess.Tasks.Add(task)
+
+	zap.L().Info("Queued filesystem refresh", zap.String("session", sess.ID), zap.String("path", path), zap.String("task", task.ID))
+	render.JSON(w, r, rbhttp.NewCommandResponse{Success: true, TaskID: task.ID})
+}
+
+// downloadRemotePath queues a download of a cached node, archiving it if it
+// is a directory.
+func downloadRemotePath(w http.ResponseWriter, r *http.Request) {
+	sess, ok := sessionFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var downloadRequest rbhttp.DownloadPathRequest
+	if err := render.Bind(r, &downloadRequest); err != nil {
+		zap.L().Error("downloadRemotePath - bind", zap.Error(err))
+		errorResponse(w, r, 400, err.Error())
+		return
+	}
+	switch downloadRequest.Format {
+	case "", rbarchive.FormatTarGz, rbarchive.FormatZip:
+	default:
+		errorResponse(w, r, 400, "format must be tar.gz or zip")
+		return
+	}
+
+	node, ok := remoteNode(w, r, sess, downloadRequest.Path, 0)
+	if !ok {
+		return
+	}
+
+	task := rbsession.NewTask(rbbrowse.DownloadCommand(node, downloadRequest.Format), false, 0)
+	task.Operator = downloadRequest.Operator
+	sess.Tasks.Add(task)
+
+	zap.L().Info("Queued filesystem download", zap.String("session", sess.ID), zap.String("path", node.Path), zap.String("task", task.ID))
+	render.JSON(w, r, rbhttp.NewCommandResponse{Success: true, TaskID: task.ID})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"redbull/internal/rbhttp"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+)
+
+// stageUpload stores a file from an operator in the uploads directory, where
+// beacons fetch it from with the upload command.
+func stageUpload(w http.ResponseWriter, r *http.Request) {
+	filename := chi.URLParam(r, "filename")
+	if filename == "" || filepath.Base(filename) != filename {
+		errorResponse(w, r, 400, errInvalidFilename.Error())
+		return
+	}
+
+	// Write to a temporary file first so beacons never fetch a partial upload
+	tmp, err := os.CreateTemp(uploadStoragePath, ".upload-*")
+	if err != nil {
+		zap.L().Error("stageUpload - create file", zap.Error(err))
+		errorResponse(w, r, 500, "failed to create file")
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r.Body); err != nil {
+		tmp.Close()
+		zap.L().Error("stageUpload - write file", zap.Error(err))
+		errorResponse(w, r, 400, "failed to write file")
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		zap.L().Error("stageUpload - close file", zap.Error(err))
+		errorResponse(w, r, 500, "failed to write file")
+		return
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(uploadStoragePath, filename)); err != nil {
+		zap.L().Error("stageUpload - rename file", zap.Error(err))
+		errorResponse(w, r, 500, "failed to write file")
+		return
+	}
+
+	zap.L().Info("Staged upload", zap.String("file", filename))
+	render.Status(r, 200)
+	render.JSON(w, r, rbhttp.FileSavedResponse{Filename: filename})
+}
+
+// fetchDownloadedFile serves a file a beacon has downloaded to the server.
+func fetchDownloadedFile(w http.ResponseWriter, r *http.Request) {
+	filename := chi.URLParam(r, "filename")
+	if filename == "" || filepath.Base(filename) != filename {
+		errorResponse(w, r, 400, errInvalidFilename.Error())
+		return
+	}
+
+	file, err := os.Open(filepath.Join(fileStoragePath, filename))
+	if os.IsNotExist(err) {
+		errorResponse(w, r, 404, "file not found")
+		return
+	}
+	if err != nil {
+		zap.L().Error("fetchDownloadedFile - open file", zap.Error(err))
+		errorResponse(w, r, 500, "failed to open file")
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		zap.L().Error("fetchDownloadedFile - stat file", zap.Error(err))
+		errorResponse(w, r, 500, "failed to open file")
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", info.Size()))
+	if _, err := io.Copy(w, file); err != nil {
+		zap.L().Error("fetchDownloadedFile - copy file", zap.Error(err))
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"redbull/internal/rbevent"
+	"redbull/internal/rbhttp"
+	"redbull/internal/rbmacro"
+	"redbull/internal/rbsession"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+)
+
+var macros *rbmacro.Store
+var macroRuns = rbmacro.NewRuns()
+
+// queueMacroStep returns the function a run uses to queue its steps on sess.
+func queueMacroStep(sess *rbsession.Session) rbmacro.QueueFunc {
+	return func(command, operator string) string {
+		task := rbsession.NewTask(command, false, 0)
+		task.Operator = operator
+		sess.Tasks.Add(task)
+		return task.ID
+	}
+}
+
+// startMacro expands the macro with args and starts running it on the session.
+func startMacro(sess *rbsession.Session, m rbmacro.Macro, args map[string]string, operator string, autoRun bool) (rbmacro.Run, error) {
+	commands, err := m.Expand(args)
+	if err != nil {
+		return rbmacro.Run{}, err
+	}
+
+	run := macroRuns.Start(m, commands, sess.ID, operator, autoRun, queueMacroStep(sess))
+	zap.L().Info("Started macro", zap.String("macro", m.Name), zap.String("session", sess.ID), zap.String("run", run.ID), zap.Bool("autoRun", autoRun))
+	return run, nil
+}
+
+// autoRunMacros starts every auto-run macro on a newly registered session.
+func autoRunMacros(sess *rbsession.Session) {
+	for _, m := range macros.AutoRun() {
+		if _, err := startMacro(sess, m, nil, "", true); err != nil {
+			zap.L().Error("autoRunMacros - start", zap.Error(err), zap.String("macro", m.Name), zap.String("session", sess.ID))
+		}
+	}
+}
+
+// macroStepDone moves along the run the task belongs to, if any. A step fails
+// when the beacon reports its command failed.
+func macroStepDone(sessionID, taskID string, failed bool) {
+	sess, ok := sessions.Get(sessionID)
+	if !ok {
+		return
+	}
+
+	run, finished := macroRuns.StepDone(taskID, failed, queueMacroStep(sess))
+	if !finished {
+		return
+	}
+
+	zap.L().Info("Macro finished", zap.String("macro", run.Macro), zap.String("session", run.SessionID), zap.String("run", run.ID), zap.String("state", run.State))
+	events.Publish(rbevent.NewEvent(rbevent.MacroFinished, run.SessionID, map[string]any{
+		"run":   run.ID,
+		"macro": run.Macro,
+		"state": run.State,
+	}))
+}
+
+func fetchMacros(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, macros.List())
+}
+
+func fetchMacro(w http.ResponseWriter, r *http.Request) {
+	m, ok := macros.Get(chi.URLParam(r, "name"))
+	if !ok {
+		errorResponse(w, r, 404, rbmacro.ErrMacroNotFound.Error())
+		return
+	}
+	render.JSON(w, r, m)
+}
+
+func putMacro(w http.ResponseWriter, r *http.Request) {
+	var m rbmacro.Macro
+	if err := render.DecodeJSON(r.Body, &m); err != nil {
+		zap.L().Error("putMacro - decode", zap.Error(err))
+		errorResponse(w, r, 400, "invalid macro")
+		return
+	}
+	m.Name = chi.URLParam(r, "name")
+
+	saved, err := macros.Put(m)
+	if err != nil {
+		errorResponse(w, r, 400, err.Error())
+		return
+	}
+
+	zap.L().Info("Saved macro", zap.String("macro", saved.Name), zap.Int("steps", len(saved.Steps)))
+	render.JSON(w, r, saved)
+}
+
+func deleteMacro(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	err := macros.Delete(name)
+	if errors.Is(err, rbmacro.ErrMacroNotFound) {
+		errorResponse(w, r, 404, err.Error())
+		return
+	}
+	if err != nil {
+		zap.L().Error("deleteMacro - delete", zap.Error(err))
+		errorResponse(w, r, 500, "failed to delete macro")
+		return
+	}
+
+	zap.L().Info("Deleted macro", zap.String("macro", name))
+	render.Status(r, 204)
+	render.NoContent(w, r)
+}
+
+func runMacro(w http.ResponseWriter, r *http.Request) {
+	m, ok := macros.Get(chi.URLParam(r, "name"))
+	if !ok {
+		errorResponse(w, r, 404, rbmacro.ErrMacroNotFound.Error())
+		return
+	}
+
+	var runRequest rbhttp.RunMacroRequest
+	if err := render.Bind(r, &runRequest); err != nil {
+		zap.L().Error("runMacro - bind", zap.Error(err))
+		errorResponse(w, r, 400, "invalid request")
+		return
+	}
+
+	sess, ok := resolveSession(runRequest.SessionID)
+	if !ok {
+		errorResponse(w, r, 404, "unknown session")
+		return
+	}
+
+	run, err := startMacro(sess, m, runRequest.Args, runRequest.Operator, false)
+	if err != nil {
+		errorResponse(w, r, 400, err.Error())
+		return
+	}
+	render.JSON(w, r, run)
+}
+
+func fetchMacroRuns(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, macroRuns.List(r.URL.Query().Get("session")))
+}
+
+func fetchMacroRun(w http.ResponseWriter, r *http.Request) {
+	run, ok := macroRuns.Get(chi.URLParam(r, "id"))
+	if !ok {
+		errorResponse(w, r, 404, rbmacro.ErrRunNotFound.Error())
+		return
+	}
+	render.JSON(w, r, run)
+}
+
+func cancelMacroRun(w http.ResponseWriter, r *http.Request) {
+	runID := chi.URLParam(r, "id")
+	run, ok := macroRuns.Get(runID)
+	if !ok {
+		errorResponse(w, r, 404, rbmacro.ErrRunNotFound.Error())
+		return
+	}
+	sess, ok := sessions.Get(run.SessionID)
+	if !ok {
+		errorResponse(w, r, 404, "session not found")
+		return
+	}
+
+	run, err := macroRuns.Cancel(runID, func(taskID string) {
+		sess.Tasks.Cancel(taskID)
+	})
+	if err != nil {
+		errorResponse(w, r, 404, err.Error())
+		return
+	}
+
+	zap.L().Info("Cancelled macro", zap.String("macro", run.Macro), zap.String("session", run.SessionID), zap.String("run", run.ID))
+	render.JSON(w, r, run)
+}
@@ -2,26 +2,26 @@ package main
 
 import (
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	config "redbull"
+	"redbull/internal/rbevent"
 	"redbull/internal/rbhttp"
-	"redbull/internal/rbqueue"
+	"redbull/internal/rbmacro"
+	"redbull/internal/rbsession"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/go-chi/render"
-	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
-var cmdQueue = rbqueue.NewQueue[string]()
+var sessions = rbsession.NewStore()
 var responses = rbhttp.NewBeaconResponses()
-var lastCheckIn time.Time
+var events = rbevent.NewBus(1000)
 var fileStoragePath string
 var uploadStoragePath string
 
@@ -36,6 +36,7 @@ func init() {
 	}
 	fileStoragePath = filepath.Join(homeDir, ".redbull", "files")
 	uploadStoragePath = filepath.Join(homeDir, ".redbull", "uploads")
+	manifestStoragePath = filepath.Join(homeDir, ".redbull", "manifests")
 
 	// Create the directories if they don't exist
 	if err := os.MkdirAll(fileStoragePath, 0755); err != nil {
@@ -44,7 +45,15 @@ func init() {
 	if err := os.MkdirAll(uploadStoragePath, 0755); err != nil {
 		zap.L().Fatal("Failed to create upload storage directory", zap.Error(err), zap.String("path", uploadStoragePath))
 	}
+	if err := os.MkdirAll(manifestStoragePath, 0755); err != nil {
+		zap.L().Fatal("Failed to create manifest storage directory", zap.Error(err), zap.String("path", manifestStoragePath))
+	}
 	zap.L().Info("File storage initialized", zap.String("files", fileStoragePath), zap.String("uploads", uploadStoragePath))
+
+	macros, err = rbmacro.NewStore(filepath.Join(homeDir, ".redbull", "macros.json"))
+	if err != nil {
+		zap.L().Fatal("Failed to load macros", zap.Error(err))
+	}
 }
 
 func errorResponse(w http.ResponseWriter, r *http.Request, status int, msg string) {
@@ -53,68 +62,15 @@ func errorResponse(w http.ResponseWriter, r *http.Request, status int, msg strin
 }
 
 func getLastCheckin(w http.ResponseWriter, r *http.Request) {
-	duration := time.Since(lastCheckIn)
-	ms := duration.Milliseconds()
-	render.JSON(w, r, rbhttp.CheckInTimeResponse{CheckInTime: fmt.Sprintf("%d", ms)})
-}
-
-func checkIn(w http.ResponseWriter, r *http.Request) {
-	cmdQueue.Lock()
-	defer cmdQueue.Unlock()
-
-	zap.L().Debug("queue", zap.Int("queue", cmdQueue.Len()), zap.Bool("empty", cmdQueue.IsEmpty()))
-	lastCheckIn = time.Now()
-	if cmdQueue.IsEmpty() {
-		render.Status(r, 204)
-		render.NoContent(w, r)
+	sess, ok := resolveSession(r.URL.Query().Get("session"))
+	if !ok {
+		errorResponse(w, r, 404, "unknown session")
 		return
 	}
 
-	command, _ := cmdQueue.Pop()
-	encodedCmd := rbhttp.EncodeCommand(command)
-	render.JSON(w, r, rbhttp.CheckInResponse{Command: encodedCmd})
-}
-
-func downloadFile(w http.ResponseWriter, r *http.Request) {
-	filename := uuid.New().String()
-	filePath := filepath.Join(fileStoragePath, filename)
-	destFile, err := os.Create(filePath)
-	if err != nil {
-		zap.L().Error("downloadFile - create file", zap.Error(err))
-		errorResponse(w, r, 500, fmt.Sprintf("failed to create file: %v", err))
-		return
-	}
-	defer destFile.Close()
-
-	// Stream directly from request body to disk
-	_, err = io.Copy(destFile, r.Body)
-	if err != nil {
-		zap.L().Error("downloadFile - copy file", zap.Error(err))
-		// Clean up partial file on error
-		os.Remove(filePath)
-		errorResponse(w, r, 400, fmt.Sprintf("failed to write file: %v", err))
-		return
-	}
-
-	responses.Append(*rbhttp.NewBeaconResponse("saved file to disk", fmt.Sprintf("saved file to disk: %s", filePath), "", uploadStoragePath))
-
-	render.Status(r, 200)
-
-	// Return just the UUID filename (not the full path) so it can be used in the upload command
-	render.JSON(w, r, map[string]string{"filename": filename})
-}
-
-func response(w http.ResponseWriter, r *http.Request) {
-	httpBody, err := rbhttp.ReadJsonBody(r)
-	if err != nil {
-		zap.L().Error("response - read body", zap.Error(err))
-		errorResponse(w, r, 400, err.Error())
-		return
-	}
-
-	responses.Append(*rbhttp.NewBeaconResponse(httpBody.Command, httpBody.Stdout, httpBody.Stderr, httpBody.CurrentDirectory))
-	render.Status(r, 204)
-	render.NoContent(w, r)
+	snapshot, _ := sessions.Snapshot(sess.ID)
+	ms := time.Since(snapshot.LastCheckIn).Milliseconds()
+	render.JSON(w, r, rbhttp.CheckInTimeResponse{CheckInTime: fmt.Sprintf("%d", ms)})
 }
 
 func newCommand(w http.ResponseWriter, r *http.Request) {
@@ -125,21 +81,26 @@ func newCommand(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cmdQueue.Lock()
-	defer cmdQueue.Unlock()
-	cmdQueue.Append(newCommandRequest.Command)
-	render.Status(r, 200)
-	render.JSON(w, r, rbhttp.NewCommandResponse{Success: true})
-}
+	sess, ok := resolveSession(newCommandRequest.SessionID)
+	if !ok {
+		errorResponse(w, r, 404, "unknown session")
+		return
+	}
 
-func fetchResponses(w http.ResponseWriter, r *http.Request) {
-	render.JSON(w, r, responses.Responses)
+	task := rbsession.NewTask(newCommandRequest.Command, newCommandRequest.Concurrent, newCommandRequest.Priority)
+	task.Operator = newCommandRequest.Operator
+	sess.Tasks.Add(task)
+	render.Status(r, 200)
+	render.JSON(w, r, rbhttp.NewCommandResponse{Success: true, TaskID: task.ID})
 }
 
 type FileInfo struct {
 	Name    string    `json:"name"`
 	Size    int64     `json:"size"`
 	ModTime time.Time `json:"modTime"`
+	// Set for archives from download -r; the manifest has the full listing
+	Archive      string `json:"archive,omitempty"`
+	ArchiveFiles int    `json:"archiveFiles,omitempty"`
 }
 
 func fetchFiles(w http.ResponseWriter, r *http.Request) {
@@ -156,67 +117,24 @@ func fetchFiles(w http.ResponseWriter, r *http.Request) {
 			zap.L().Error("fetchFiles - get info", zap.Error(err))
 			continue
 		}
-		fileInfos = append(fileInfos, FileInfo{
+		fileInfo := FileInfo{
 			Name:    f.Name(),
 			Size:    info.Size(),
 			ModTime: info.ModTime(),
-		})
-	}
-	render.JSON(w, r, fileInfos)
-}
-
-func downloadFileFromServer(w http.ResponseWriter, r *http.Request) {
-	filename := chi.URLParam(r, "filename")
-	if filename == "" {
-		errorResponse(w, r, 400, "filename is required")
-		return
-	}
-
-	if filepath.Base(filename) != filename {
-		errorResponse(w, r, 400, "invalid filename")
-		return
-	}
-
-	// Serve files from uploads directory (where UI uploads go)
-	filePath := filepath.Join(uploadStoragePath, filename)
-
-	// Check if file exists
-	fileInfo, err := os.Stat(filePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			errorResponse(w, r, 404, "file not found")
-			return
 		}
-		zap.L().Error("downloadFileFromServer - stat file", zap.Error(err))
-		errorResponse(w, r, 500, fmt.Sprintf("failed to access file: %v", err))
-		return
-	}
-
-	// Open the file
-	file, err := os.Open(filePath)
-	if err != nil {
-		zap.L().Error("downloadFileFromServer - open file", zap.Error(err))
-		errorResponse(w, r, 500, fmt.Sprintf("failed to open file: %v", err))
-		return
-	}
-	defer file.Close()
-
-	// Set headers for file download
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
-	w.Header().Set("Content-Type", "application/octet-stream")
-	w.Header().Set("Content-Length", fmt.Sprintf("%d", fileInfo.Size()))
-
-	// Stream the file to the response
-	_, err = io.Copy(w, file)
-	if err != nil {
-		zap.L().Error("downloadFileFromServer - copy file", zap.Error(err))
-		return
+		if manifest, err := loadManifest(f.Name()); err == nil {
+			fileInfo.Archive = manifest.Format
+			fileInfo.ArchiveFiles = manifest.Files
+		}
+		fileInfos = append(fileInfos, fileInfo)
 	}
+	render.JSON(w, r, fileInfos)
 }
 
 func main() {
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
+	r.Use(instrument)
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Recoverer)
@@ -228,19 +146,55 @@ func main() {
 		// AllowOriginFunc:  func(r *http.Request, origin string) bool { return true },
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "x-auth-token"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "X-Next-Cursor"},
 		AllowCredentials: false,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
 
-	r.Get("/", checkIn)
-	r.Post("/", response)
+	transport := &httpTransport{handler: &beaconHandler{}}
+	transport.Routes(r)
+
+	r.Get("/sessions", fetchSessions)
+	r.Get("/sessions/overview", fetchSessionsOverview)
+	r.Get("/sessions/{id}", fetchSession)
+	r.Get("/sessions/{id}/tasks", fetchTasks)
+	r.Delete("/sessions/{id}/tasks", clearTasks)
+	r.Delete("/sessions/{id}/tasks/{taskId}", cancelTask)
+	r.Put("/sessions/{id}/tasks/{taskId}/priority", setTaskPriority)
+	r.Get("/sessions/{id}/fs", fetchRemoteDirs)
+	r.Get("/sessions/{id}/fs/node", fetchRemoteNode)
+	r.Post("/sessions/{id}/fs/refresh", refreshRemotePath)
+	r.Post("/sessions/{id}/fs/download", downloadRemotePath)
+	r.Get("/sessions/{id}/findings", fetchFindings)
+	r.Get("/sessions/{id}/ptys", fetchPtys)
+	r.Post("/sessions/{id}/ptys", openPty)
+	r.Get("/ptys/{ptyId}", fetchPty)
+	r.Delete("/ptys/{ptyId}", closePty)
+	r.Get("/ptys/{ptyId}/ws", attachPty)
 	r.Post("/command", newCommand)
 	r.Get("/responses", fetchResponses)
 	r.Get("/last_checkin", getLastCheckin)
-	r.Post("/download", downloadFile)
 	r.Get("/files", fetchFiles)
-	r.Get("/files/{filename}", downloadFileFromServer)
+	r.Put("/uploads/{filename}", stageUpload)
+	r.Get("/downloads/{filename}", fetchDownloadedFile)
+	r.Get("/downloads/{filename}/manifest", fetchManifest)
+	r.Get("/downloads/{filename}/entry", fetchArchiveEntry)
+	r.Get("/events", fetchEvents)
+	r.Get("/search", search)
+	r.Get("/metrics", fetchMetrics)
+	r.Get("/macros", fetchMacros)
+	r.Get("/macros/{name}", fetchMacro)
+	r.Put("/macros/{name}", putMacro)
+	r.Delete("/macros/{name}", deleteMacro)
+	r.Post("/macros/{name}/run", runMacro)
+	r.Get("/macro_runs", fetchMacroRuns)
+	r.Get("/macro_runs/{id}", fetchMacroRun)
+	r.Delete("/macro_runs/{id}", cancelMacroRun)
+
+	setupNotifications()
+	indexStoredFiles()
+	go watchSessions()
+	go watchPtys()
 
 	zap.L().Info("Server running", zap.Int("port", config.PORT_NUMBER))
 	http.ListenAndServe(fmt.Sprintf("0.0.0.0:%d", config.PORT_NUMBER), r)
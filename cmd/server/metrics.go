@@ -0,0 +1,167 @@
+package main
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"redbull/internal/rbmetrics"
+	"redbull/internal/rbsession"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+)
+
+var (
+	metrics = rbmetrics.NewRegistry()
+
+	checkInsTotal = metrics.Counter("redbull_checkins_total", "Beacon check-ins by session.", "session")
+	queueDepth    = metrics.Gauge("redbull_task_queue_depth", "Tasks waiting to be sent, by session.", "session")
+	inFlightTasks = metrics.Gauge("redbull_tasks_in_flight", "Tasks sent to the beacon without a result yet, by session.", "session")
+	sessionsTotal = metrics.Gauge("redbull_sessions", "Known sessions by health.", "health")
+	tasksTotal    = metrics.Counter("redbull_tasks_total", "Task results received, by outcome.", "result")
+	taskLatency   = metrics.Histogram("redbull_task_latency_seconds", "Time a task spent in each stage: queued (queued to sent), running (sent to completed) and total (queued to completed).", rbmetrics.DefaultBuckets, "stage")
+	bytesTotal    = metrics.Counter("redbull_transferred_bytes_total", "Bytes moved between beacons and the server, by kind (task, result, file, pty) and whether the server sent or received them.", "kind", "direction")
+	httpRequests  = metrics.Counter("redbull_http_requests_total", "HTTP requests by route, method and status.", "route", "method", "status")
+	httpErrors    = metrics.Counter("redbull_http_errors_total", "HTTP responses with a 4xx or 5xx status, by route and status.", "route", "status")
+	httpBytes     = metrics.Counter("redbull_http_bytes_total", "HTTP body bytes by route and direction.", "route", "direction")
+	httpDuration  = metrics.Histogram("redbull_http_request_duration_seconds", "Time to serve HTTP requests, by route.", []float64{0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 30}, "route")
+	storageBytes  = metrics.Gauge("redbull_storage_bytes", "Size of the server's file storage, by directory.", "directory")
+	storageFiles  = metrics.Gauge("redbull_storage_files", "Files in the server's file storage, by directory.", "directory")
+)
+
+func init() {
+	metrics.OnScrape(refreshGauges)
+}
+
+// storageRefreshInterval limits how often scrapes walk the file storage,
+// which grows slow to walk over a long engagement.
+const storageRefreshInterval = 30 * time.Second
+
+// storageWalk records when the storage gauges were last refreshed.
+var storageWalk struct {
+	at time.Time
+	sync.Mutex
+}
+
+// refreshGauges recomputes the gauges that are cheaper to work out at scrape
+// time than to keep up to date.
+func refreshGauges() {
+	list := sessions.List()
+	queueDepth.Replace(func(add func(float64, ...string)) {
+		for _, sess := range list {
+			add(float64(sess.Tasks.Len()), sess.ID)
+		}
+	})
+	inFlightTasks.Replace(func(add func(float64, ...string)) {
+		for _, sess := range list {
+			add(float64(len(sess.Tasks.InFlight())), sess.ID)
+		}
+	})
+	sessionsTotal.Replace(func(add func(float64, ...string)) {
+		for _, health := range []rbsession.Health{rbsession.HealthActive, rbsession.HealthLate, rbsession.HealthDead} {
+			add(0, string(health))
+		}
+		for _, sess := range list {
+			add(1, string(sess.Health))
+		}
+	})
+
+	refreshStorageGauges()
+}
+
+// refreshStorageGauges walks the file storage, unless it was walked within
+// storageRefreshInterval. Concurrent scrapes wait for one walk rather than
+// each starting their own.
+func refreshStorageGauges() {
+	storageWalk.Lock()
+	defer storageWalk.Unlock()
+	if time.Since(storageWalk.at) < storageRefreshInterval {
+		return
+	}
+	storageWalk.at = time.Now()
+
+	for name, dir := range map[string]string{"files": fileStoragePath, "uploads": uploadStoragePath} {
+		var size int64
+		count := 0
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			size += info.Size()
+			count++
+			return nil
+		})
+		if err != nil {
+			zap.L().Error("refreshStorageGauges - walk storage", zap.Error(err), zap.String("path", dir))
+		}
+		storageBytes.Set(float64(size), name)
+		storageFiles.Set(float64(count), name)
+	}
+}
+
+// observeTask records how long a completed task spent queued and running.
+func observeTask(task rbsession.Task, completedAt time.Time) {
+	taskLatency.Observe(completedAt.Sub(task.QueuedAt).Seconds(), "total")
+	if task.SentAt != nil {
+		taskLatency.Observe(task.SentAt.Sub(task.QueuedAt).Seconds(), "queued")
+		taskLatency.Observe(completedAt.Sub(*task.SentAt).Seconds(), "running")
+	}
+}
+
+func fetchMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if _, err := metrics.WriteTo(w); err != nil {
+		zap.L().Error("fetchMetrics - write", zap.Error(err))
+	}
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// instrument records request counts, errors, sizes and durations by chi
+// route pattern, so IDs in the path do not become separate series.
+func instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		body := &countingReader{ReadCloser: r.Body}
+		r.Body = body
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		httpRequests.Inc(route, r.Method, strconv.Itoa(status))
+		if status >= 400 {
+			httpErrors.Inc(route, strconv.Itoa(status))
+		}
+		httpBytes.Add(float64(body.n), route, "in")
+		httpBytes.Add(float64(ww.BytesWritten()), route, "out")
+		httpDuration.Observe(time.Since(start).Seconds(), route)
+	})
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	config "redbull"
+	"redbull/internal/rbnotify"
+
+	"go.uber.org/zap"
+)
+
+// setupNotifications subscribes the configured sinks to the event bus. A sink
+// that cannot be set up is logged and skipped rather than stopping the server.
+func setupNotifications() {
+	dispatcher := rbnotify.NewDispatcher()
+	for i, cfg := range config.NOTIFY_SINKS {
+		sink, err := rbnotify.NewSink(rbnotify.SinkConfig(cfg))
+		if err != nil {
+			zap.L().Error("Failed to set up notification sink", zap.Error(err), zap.Int("sink", i))
+			continue
+		}
+		dispatcher.Add(sink, cfg.Events)
+		zap.L().Info("Sending events", zap.String("sink", sink.Name()), zap.Strings("events", cfg.Events))
+	}
+	events.Subscribe(dispatcher.Publish)
+}
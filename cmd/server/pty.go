@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	config "redbull"
+	"redbull/internal/rbbrowse"
+	"redbull/internal/rbhttp"
+	"redbull/internal/rbpty"
+	"redbull/internal/rbsession"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+var ptys = rbpty.NewRelay()
+
+var upgrader = websocket.Upgrader{CheckOrigin: allowedOrigin}
+
+// allowedOrigin only lets a browser attach to a terminal from the server's
+// own origin or one in PTY_ALLOWED_ORIGINS, so a page an operator happens to
+// visit cannot open a shell on a beacon. Clients other than browsers, like
+// rbctl, send no Origin.
+func allowedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range config.PTY_ALLOWED_ORIGINS {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	zap.L().Warn("Refused pty WebSocket from another origin", zap.String("origin", origin))
+	return false
+}
+
+// watchPtys periodically forgets terminals that closed more than
+// PTY_RETENTION_MINUTES ago.
+func watchPtys() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	retention := time.Duration(config.PTY_RETENTION_MINUTES) * time.Minute
+	for now := range ticker.C {
+		if pruned := ptys.Prune(now.Add(-retention)); pruned > 0 {
+			zap.L().Debug("Pruned closed ptys", zap.Int("pruned", pruned))
+		}
+	}
+}
+
+// openPty queues a task for the beacon to start a shell under a pty. The
+// terminal can be attached to straight away; output starts once the beacon
+// has picked up the task.
+func openPty(w http.ResponseWriter, r *http.Request) {
+	sess, ok := sessionFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var openRequest rbhttp.OpenPtyRequest
+	if err := render.Bind(r, &openRequest); err != nil {
+		zap.L().Error("openPty - bind", zap.Error(err))
+		errorResponse(w, r, 400, err.Error())
+		return
+	}
+	if openRequest.Cols == 0 || openRequest.Rows == 0 {
+		openRequest.Cols, openRequest.Rows = 80, 24
+	}
+
+	id := uuid.New().String()
+	command := fmt.Sprintf("pty open -id %s -cols %d -rows %d", id, openRequest.Cols, openRequest.Rows)
+	if openRequest.Shell != "" {
+		command += " -- " + rbbrowse.QuoteArg(openRequest.Shell)
+	}
+	task := rbsession.NewTask(command, false, 0)
+	task.Operator = openRequest.Operator
+	terminal := ptys.Open(rbpty.Terminal{
+		ID:        id,
+		SessionID: sess.ID,
+		TaskID:    task.ID,
+		Shell:     openRequest.Shell,
+		Cols:      openRequest.Cols,
+		Rows:      openRequest.Rows,
+		Operator:  openRequest.Operator,
+	})
+	sess.Tasks.Add(task)
+
+	zap.L().Info("Queued pty", zap.String("session", sess.ID), zap.String("pty", id), zap.String("task", task.ID))
+	render.Status(r, 201)
+	render.JSON(w, r, terminal)
+}
+
+func fetchPtys(w http.ResponseWriter, r *http.Request) {
+	sess, ok := sessionFromURL(w, r)
+	if !ok {
+		return
+	}
+	render.JSON(w, r, ptys.List(sess.ID))
+}
+
+func fetchPty(w http.ResponseWriter, r *http.Request) {
+	terminal, ok := ptys.Get(chi.URLParam(r, "ptyId"))
+	if !ok {
+		errorResponse(w, r, 404, rbpty.ErrPtyNotFound.Error())
+		return
+	}
+	render.JSON(w, r, terminal)
+}
+
+func closePty(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ptyId")
+	if err := ptys.Close(id); err != nil {
+		ptyErrorResponse(w, r, err)
+		return
+	}
+
+	terminal, _ := ptys.Get(id)
+	zap.L().Info("Closing pty", zap.String("session", terminal.SessionID), zap.String("pty", id))
+	render.JSON(w, r, terminal)
+}
+
+func ptyErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, rbpty.ErrPtyNotFound):
+		errorResponse(w, r, 404, err.Error())
+	case errors.Is(err, rbpty.ErrPtyClosed):
+		errorResponse(w, r, 409, err.Error())
+	default:
+		errorResponse(w, r, 500, err.Error())
+	}
+}
+
+// attachPty relays a terminal over a WebSocket. Several clients can attach to
+// the same terminal; each sees its recent output first.
+func attachPty(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ptyId")
+	terminal, backlog, events, detach, err := ptys.Attach(id)
+	if err != nil {
+		ptyErrorResponse(w, r, err)
+		return
+	}
+	defer detach()
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already written the error response
+		zap.L().Error("attachPty - upgrade", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+	zap.L().Info("Attached to pty", zap.String("session", terminal.SessionID), zap.String("pty", id), zap.String("remoteAddr", r.RemoteAddr))
+
+	go readPtyMessages(conn, id, detach)
+
+	if len(backlog) > 0 {
+		if err := conn.WriteMessage(websocket.BinaryMessage, backlog); err != nil {
+			return
+		}
+	}
+	for event := range events {
+		if event.Closed {
+			conn.WriteJSON(rbhttp.PtyMessage{Type: "exit", ExitCode: event.ExitCode, Error: event.Error})
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+			return
+		}
+		if err := conn.WriteMessage(websocket.BinaryMessage, event.Data); err != nil {
+			return
+		}
+	}
+}
+
+// readPtyMessages applies what the client sends until it goes away, then
+// detaches it, which ends the output loop in attachPty.
+func readPtyMessages(conn *websocket.Conn, id string, detach func()) {
+	defer detach()
+
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		// Raw frames are keystrokes, for clients that do not bother with JSON
+		if messageType == websocket.BinaryMessage {
+			ptys.Input(id, data)
+			continue
+		}
+
+		var message rbhttp.PtyMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			zap.L().Error("readPtyMessages - unmarshal", zap.Error(err), zap.String("pty", id))
+			continue
+		}
+		switch message.Type {
+		case "input":
+			err = ptys.Input(id, []byte(message.Data))
+		case "interrupt":
+			err = ptys.Input(id, []byte{0x03})
+		case "resize":
+			if message.Cols > 0 && message.Rows > 0 {
+				err = ptys.Resize(id, message.Cols, message.Rows)
+			}
+		case "close":
+			err = ptys.Close(id)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"redbull/internal/rbhttp"
+	"redbull/internal/rbparse"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+)
+
+const maxResponsesPage = 1000
+
+// fetchResponses lists stored responses, oldest first. Without a limit every
+// matching response is returned, as the UI has always expected; with one,
+// the cursor for the next page is sent in the X-Next-Cursor and Link headers.
+func fetchResponses(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter, err := parseResponseFilter(query)
+	if err != nil {
+		errorResponse(w, r, 400, err.Error())
+		return
+	}
+
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			errorResponse(w, r, 400, "invalid limit")
+			return
+		}
+		limit = min(limit, maxResponsesPage)
+	}
+
+	page, next, err := responses.Query(filter, query.Get("cursor"), limit)
+	if err != nil {
+		zap.L().Error("fetchResponses - query", zap.Error(err))
+		errorResponse(w, r, 400, "invalid cursor")
+		return
+	}
+
+	if next != "" {
+		query.Set("cursor", next)
+		w.Header().Set("X-Next-Cursor", next)
+		w.Header().Set("Link", fmt.Sprintf("<%s?%s>; rel=\"next\"", r.URL.Path, query.Encode()))
+	}
+	render.JSON(w, r, page)
+}
+
+// fetchFindings summarises the session's latest parsed output of each kind,
+// such as its processes or listening sockets.
+func fetchFindings(w http.ResponseWriter, r *http.Request) {
+	sess, ok := sessionFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	page, _, err := responses.Query(rbhttp.ResponseFilter{SessionID: sess.ID}, "", 0)
+	if err != nil {
+		zap.L().Error("fetchFindings - query", zap.Error(err))
+		errorResponse(w, r, 500, err.Error())
+		return
+	}
+
+	// Responses come oldest first, so later output of a kind replaces earlier
+	latest := make(map[string]rbhttp.BeaconResponse)
+	for _, resp := range page {
+		if resp.ParsedKind == "" {
+			continue
+		}
+		key := resp.ParsedKind
+		if key == rbparse.KindJSON {
+			// Generic JSON from different commands has nothing in common
+			key += " " + rbparse.ParseCommand(resp.Command).Name
+		}
+		latest[key] = resp
+	}
+
+	findings := make([]rbhttp.Finding, 0, len(latest))
+	for _, resp := range latest {
+		var parsed rbparse.Result
+		if err := json.Unmarshal(resp.Parsed, &parsed); err != nil {
+			zap.L().Error("fetchFindings - decode parsed output", zap.Error(err), zap.String("response", resp.ID))
+			continue
+		}
+		findings = append(findings, rbhttp.Finding{
+			Kind:       parsed.Kind,
+			Parser:     parsed.Parser,
+			Summary:    parsed.Summary,
+			ResponseID: resp.ID,
+			TaskID:     resp.TaskID,
+			Command:    resp.Command,
+			Time:       resp.Time,
+		})
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Kind != findings[j].Kind {
+			return findings[i].Kind < findings[j].Kind
+		}
+		return findings[i].Command < findings[j].Command
+	})
+	render.JSON(w, r, findings)
+}
+
+// setParsed stores the structured form of a response's output, if any parser
+// recognises it.
+func setParsed(response *rbhttp.BeaconResponse) {
+	parsed := rbparse.Parse(response.Command, response.Stdout)
+	if parsed == nil {
+		return
+	}
+	data, err := json.Marshal(parsed)
+	if err != nil {
+		zap.L().Error("setParsed - encode parsed output", zap.Error(err), zap.String("command", response.Command))
+		return
+	}
+	response.Parsed = data
+	response.ParsedKind = parsed.Kind
+}
+
+func parseResponseFilter(query url.Values) (rbhttp.ResponseFilter, error) {
+	filter := rbhttp.ResponseFilter{
+		SessionID:   query.Get("session"),
+		TaskID:      query.Get("task"),
+		Operator:    query.Get("operator"),
+		CommandName: query.Get("command"),
+		Text:        query.Get("q"),
+		Kind:        query.Get("kind"),
+	}
+
+	times := map[string]*time.Time{"from": &filter.From, "to": &filter.To, "since": &filter.Since}
+	for name, dest := range times {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s time", name)
+		}
+		*dest = t
+	}
+	return filter, nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"redbull/internal/rbhttp"
+	"redbull/internal/rbsearch"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+)
+
+// Files larger than this are not indexed; they are rarely text worth searching
+const maxIndexedFileSize = 8 << 20
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 500
+)
+
+var searchIndex = rbsearch.NewIndex()
+
+type SearchResponse struct {
+	Query string         `json:"query"`
+	Hits  []rbsearch.Hit `json:"hits"`
+}
+
+func indexResponse(response rbhttp.BeaconResponse) {
+	text := response.Stdout
+	if response.Stderr != "" {
+		text += "\n" + response.Stderr
+	}
+	if text == "" {
+		return
+	}
+
+	searchIndex.Add(rbsearch.Document{
+		ID:        response.ID,
+		Kind:      rbsearch.KindResponse,
+		SessionID: response.SessionID,
+		TaskID:    response.TaskID,
+		Title:     response.Command,
+		Time:      response.Time,
+		Text:      text,
+	})
+}
+
+// indexFile adds a downloaded file to the search index if it looks like text.
+func indexFile(name, sessionID string) error {
+	filePath := filepath.Join(fileStoragePath, name)
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to access file: %w", err)
+	}
+	if info.Size() > maxIndexedFileSize {
+		return nil
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	if !isText(content) {
+		return nil
+	}
+
+	searchIndex.Add(rbsearch.Document{
+		ID:        name,
+		Kind:      rbsearch.KindFile,
+		SessionID: sessionID,
+		Title:     name,
+		Time:      info.ModTime(),
+		Text:      string(content),
+	})
+	return nil
+}
+
+func isText(content []byte) bool {
+	return utf8.Valid(content) && !bytes.Contains(content, []byte{0})
+}
+
+// indexStoredFiles indexes files downloaded before the server started. Which
+// session they came from is not recorded on disk, so they have none.
+func indexStoredFiles() {
+	files, err := os.ReadDir(fileStoragePath)
+	if err != nil {
+		zap.L().Error("indexStoredFiles - read directory", zap.Error(err))
+		return
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		if err := indexFile(f.Name(), ""); err != nil {
+			zap.L().Error("indexStoredFiles - index file", zap.Error(err), zap.String("file", f.Name()))
+		}
+	}
+	zap.L().Info("Search index loaded", zap.Int("documents", searchIndex.Len()))
+}
+
+func search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	text := query.Get("q")
+	if text == "" {
+		errorResponse(w, r, 400, "q is required")
+		return
+	}
+
+	kind := query.Get("kind")
+	if kind != "" && kind != rbsearch.KindResponse && kind != rbsearch.KindFile {
+		errorResponse(w, r, 400, "invalid kind")
+		return
+	}
+
+	limit := defaultSearchLimit
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			errorResponse(w, r, 400, "invalid limit")
+			return
+		}
+		limit = min(n, maxSearchLimit)
+	}
+
+	hits := searchIndex.Search(rbsearch.Query{
+		Text:      text,
+		SessionID: query.Get("session"),
+		Kind:      kind,
+		Limit:     limit,
+	})
+	render.JSON(w, r, SearchResponse{Query: text, Hits: hits})
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"net/http"
+	config "redbull"
+	"redbull/internal/rbevent"
+	"redbull/internal/rbhttp"
+	"redbull/internal/rbsession"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+)
+
+// resolveSession looks up the named session, falling back to the most recently
+// seen one when the operator did not specify a session.
+func resolveSession(id string) (*rbsession.Session, bool) {
+	if id == "" {
+		return sessions.Latest()
+	}
+	return sessions.Get(id)
+}
+
+func fetchSessions(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, sessions.List())
+}
+
+func fetchSession(w http.ResponseWriter, r *http.Request) {
+	sess, ok := sessions.Snapshot(chi.URLParam(r, "id"))
+	if !ok {
+		errorResponse(w, r, 404, "session not found")
+		return
+	}
+	render.JSON(w, r, sess)
+}
+
+var healthEvents = map[rbsession.Health]string{
+	rbsession.HealthActive: rbevent.SessionActive,
+	rbsession.HealthLate:   rbevent.SessionLate,
+	rbsession.HealthDead:   rbevent.SessionDead,
+}
+
+func healthThresholds() rbsession.Thresholds {
+	return rbsession.Thresholds{
+		LateAfter: config.SESSION_LATE_AFTER,
+		DeadAfter: config.SESSION_DEAD_AFTER,
+		Grace:     time.Duration(config.SESSION_GRACE_SECONDS) * time.Second,
+	}
+}
+
+// watchSessions periodically recomputes session health and publishes an event
+// whenever a beacon changes state.
+func watchSessions() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		for _, t := range sessions.RefreshHealth(now, healthThresholds()) {
+			zap.L().Warn("Session health changed", zap.String("session", t.Session.ID), zap.String("from", string(t.From)), zap.String("to", string(t.To)))
+			events.Publish(rbevent.NewEvent(healthEvents[t.To], t.Session.ID, map[string]any{
+				"from":        t.From,
+				"hostname":    t.Session.Host.Hostname,
+				"lastCheckIn": t.Session.LastCheckIn,
+			}))
+		}
+	}
+}
+
+func fetchSessionsOverview(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	thresholds := healthThresholds()
+	overview := rbhttp.SessionsOverviewResponse{
+		Counts:   map[string]int{},
+		Sessions: make([]rbhttp.SessionOverview, 0),
+	}
+
+	for _, sess := range sessions.List() {
+		overview.Counts[string(sess.Health)]++
+		overview.Sessions = append(overview.Sessions, rbhttp.SessionOverview{
+			ID:             sess.ID,
+			Hostname:       sess.Host.Hostname,
+			Username:       sess.Host.Username,
+			Health:         string(sess.Health),
+			SleepTime:      sess.SleepTime,
+			LastCheckIn:    sess.LastCheckIn,
+			SinceCheckInMs: now.Sub(sess.LastCheckIn).Milliseconds(),
+			MissedCheckIns: sess.MissedCheckIns(now, thresholds),
+		})
+	}
+	render.JSON(w, r, overview)
+}
+
+func fetchEvents(w http.ResponseWriter, r *http.Request) {
+	var since time.Time
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			errorResponse(w, r, 400, "since must be an RFC3339 timestamp")
+			return
+		}
+		since = parsed
+	}
+	render.JSON(w, r, events.Recent(since))
+}
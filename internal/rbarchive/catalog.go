@@ -0,0 +1,188 @@
+package rbarchive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+var ErrEntryNotFound = errors.New("entry not found in archive")
+
+// Entry is a file inside an archive.
+type Entry struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	Mode    string    `json:"mode"`
+	ModTime time.Time `json:"modTime"`
+	SHA256  string    `json:"sha256"`
+}
+
+// Manifest lists the contents of an archive, so operators can see what it
+// holds without extracting it.
+type Manifest struct {
+	Archive   string    `json:"archive"`
+	Format    string    `json:"format"`
+	Files     int       `json:"files"`
+	TotalSize int64     `json:"totalSize"`
+	Entries   []Entry   `json:"entries"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Detect reports the archive format of the file at path from its first
+// bytes, or "" if it is not an archive this package reads.
+func Detect(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(file, magic); err != nil {
+		return "", nil
+	}
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return FormatTarGz, nil
+	case bytes.Equal(magic, []byte("PK\x03\x04")):
+		return FormatZip, nil
+	default:
+		return "", nil
+	}
+}
+
+// Catalog reads the archive at path and hashes every file in it.
+func Catalog(path, format string) (Manifest, error) {
+	manifest := Manifest{
+		Format:    format,
+		Entries:   make([]Entry, 0),
+		CreatedAt: time.Now(),
+	}
+
+	err := walk(path, format, func(entry Entry, body io.Reader) (bool, error) {
+		hash := sha256.New()
+		if _, err := io.Copy(hash, body); err != nil {
+			return false, err
+		}
+		entry.SHA256 = hex.EncodeToString(hash.Sum(nil))
+		manifest.Entries = append(manifest.Entries, entry)
+		manifest.Files++
+		manifest.TotalSize += entry.Size
+		return true, nil
+	})
+	return manifest, err
+}
+
+// Extract writes the named file from the archive at path to w.
+func Extract(path, format, name string, w io.Writer) error {
+	found := false
+	err := walk(path, format, func(entry Entry, body io.Reader) (bool, error) {
+		if entry.Path != name {
+			return true, nil
+		}
+		found = true
+		_, err := io.Copy(w, body)
+		return false, err
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrEntryNotFound
+	}
+	return nil
+}
+
+// walk calls fn with each regular file in the archive until fn returns false.
+func walk(path, format string, fn func(entry Entry, body io.Reader) (bool, error)) error {
+	switch format {
+	case FormatTarGz:
+		return walkTarGz(path, fn)
+	case FormatZip:
+		return walkZip(path, fn)
+	default:
+		return fmt.Errorf("unknown archive format '%s'", format)
+	}
+}
+
+func walkTarGz(path string, fn func(entry Entry, body io.Reader) (bool, error)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		entry := Entry{
+			Path:    header.Name,
+			Size:    header.Size,
+			Mode:    header.FileInfo().Mode().String(),
+			ModTime: header.ModTime,
+		}
+		more, err := fn(entry, reader)
+		if err != nil || !more {
+			return err
+		}
+	}
+}
+
+func walkZip(path string, fn func(entry Entry, body io.Reader) (bool, error)) error {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer reader.Close()
+
+	for _, f := range reader.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+
+		entry := Entry{
+			Path:    f.Name,
+			Size:    int64(f.UncompressedSize64),
+			Mode:    f.Mode().String(),
+			ModTime: f.Modified,
+		}
+		more, err := walkZipFile(f, entry, fn)
+		if err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkZipFile(f *zip.File, entry Entry, fn func(entry Entry, body io.Reader) (bool, error)) (bool, error) {
+	body, err := f.Open()
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	defer body.Close()
+	return fn(entry, body)
+}
@@ -0,0 +1,201 @@
+package rbarchive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	FormatTarGz = "tar.gz"
+	FormatZip   = "zip"
+)
+
+// Options choose what goes into an archive. Globs match either a file's name
+// or its path relative to the archived directory. With Include set, only
+// matching files are archived; Exclude wins over Include, and an excluded
+// directory is not descended into. Zero size limits mean no limit.
+type Options struct {
+	Format       string
+	Include      []string
+	Exclude      []string
+	MaxFileSize  int64
+	MaxTotalSize int64
+}
+
+// Stats describe what Write put into the archive and what it left out.
+type Stats struct {
+	Files   int      `json:"files"`
+	Bytes   int64    `json:"bytes"`
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// archiveWriter is the part of tar and zip writing that differs by format.
+type archiveWriter interface {
+	add(name string, info fs.FileInfo) (io.Writer, error)
+	Close() error
+}
+
+// Write streams the regular files under root into w as an archive. Files are
+// read one at a time, so memory use does not grow with the size of the tree.
+// Symlinks and special files are skipped rather than followed.
+func Write(ctx context.Context, w io.Writer, root string, opts Options) (Stats, error) {
+	for _, pattern := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return Stats{}, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+		}
+	}
+
+	var archive archiveWriter
+	switch opts.Format {
+	case FormatTarGz, "":
+		archive = newTarGzWriter(w)
+	case FormatZip:
+		archive = &zipWriter{zip.NewWriter(w)}
+	default:
+		return Stats{}, fmt.Errorf("unknown archive format '%s': expected %s or %s", opts.Format, FormatTarGz, FormatZip)
+	}
+
+	stats := Stats{Skipped: make([]string, 0)}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			stats.Skipped = append(stats.Skipped, fmt.Sprintf("%s: %v", path, err))
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if matchesAny(opts.Exclude, rel) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if len(opts.Include) > 0 && !matchesAny(opts.Include, rel) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			stats.Skipped = append(stats.Skipped, fmt.Sprintf("%s: %v", rel, err))
+			return nil
+		}
+		switch {
+		case !info.Mode().IsRegular():
+			stats.Skipped = append(stats.Skipped, fmt.Sprintf("%s: not a regular file", rel))
+			return nil
+		case opts.MaxFileSize > 0 && info.Size() > opts.MaxFileSize:
+			stats.Skipped = append(stats.Skipped, fmt.Sprintf("%s: larger than %d bytes", rel, opts.MaxFileSize))
+			return nil
+		case opts.MaxTotalSize > 0 && stats.Bytes+info.Size() > opts.MaxTotalSize:
+			stats.Skipped = append(stats.Skipped, fmt.Sprintf("%s: would exceed the %d byte total", rel, opts.MaxTotalSize))
+			return nil
+		}
+
+		if err := addFile(archive, path, rel, info); err != nil {
+			// The archive is corrupt once a write fails part way, so stop
+			return fmt.Errorf("failed to archive %s: %w", rel, err)
+		}
+		stats.Files++
+		stats.Bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+	return stats, archive.Close()
+}
+
+func addFile(archive archiveWriter, path, name string, info fs.FileInfo) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w, err := archive.add(name, info)
+	if err != nil {
+		return err
+	}
+	// Copy exactly the size in the header, in case the file grows meanwhile
+	_, err = io.CopyN(w, file, info.Size())
+	return err
+}
+
+func matchesAny(patterns []string, rel string) bool {
+	name := filepath.Base(rel)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type tarGzWriter struct {
+	gz  *gzip.Writer
+	tar *tar.Writer
+}
+
+func newTarGzWriter(w io.Writer) *tarGzWriter {
+	gz := gzip.NewWriter(w)
+	return &tarGzWriter{gz: gz, tar: tar.NewWriter(gz)}
+}
+
+func (t *tarGzWriter) add(name string, info fs.FileInfo) (io.Writer, error) {
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return nil, err
+	}
+	header.Name = name
+	if err := t.tar.WriteHeader(header); err != nil {
+		return nil, err
+	}
+	return t.tar, nil
+}
+
+func (t *tarGzWriter) Close() error {
+	if err := t.tar.Close(); err != nil {
+		return err
+	}
+	return t.gz.Close()
+}
+
+type zipWriter struct {
+	*zip.Writer
+}
+
+func (z *zipWriter) add(name string, info fs.FileInfo) (io.Writer, error) {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return nil, err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+	return z.CreateHeader(header)
+}
@@ -0,0 +1,160 @@
+package rbbrowse
+
+import (
+	"encoding/json"
+	"fmt"
+	"redbull/internal/rbresult"
+	"strings"
+	"sync"
+	"time"
+)
+
+// refresh is an ls the browser queued and is waiting on.
+type refresh struct {
+	sessionID string
+	path      string
+	depth     int
+}
+
+// Browser keeps a Tree for every session, filled in from the results of ls
+// tasks: the refreshes it queues itself, and any ls -json an operator runs.
+type Browser struct {
+	trees     map[string]*Tree
+	refreshes map[string]refresh
+	sync.Mutex
+}
+
+func NewBrowser() *Browser {
+	return &Browser{
+		trees:     make(map[string]*Tree),
+		refreshes: make(map[string]refresh),
+	}
+}
+
+// Tree returns the cached tree of a session, if anything has been listed.
+func (b *Browser) Tree(sessionID string) (*Tree, bool) {
+	b.Lock()
+	defer b.Unlock()
+	tree, ok := b.trees[sessionID]
+	return tree, ok
+}
+
+func (b *Browser) tree(sessionID string) *Tree {
+	b.Lock()
+	defer b.Unlock()
+	tree, ok := b.trees[sessionID]
+	if !ok {
+		tree = NewTree()
+		b.trees[sessionID] = tree
+	}
+	return tree
+}
+
+// RefreshCommand is the ls that lists path with depth levels of
+// subdirectories. An empty path lists the beacon's working directory.
+func RefreshCommand(path string, depth int) string {
+	cmd := "ls -json"
+	if depth > 0 {
+		cmd += fmt.Sprintf(" -depth %d", depth)
+	}
+	if path != "" {
+		cmd += " -- " + QuoteArg(path)
+	}
+	return cmd
+}
+
+// DownloadCommand is the download of a node, as an archive for directories.
+func DownloadCommand(node Node, format string) string {
+	if node.Type != "dir" {
+		return "download -- " + QuoteArg(node.Path)
+	}
+	cmd := "download -r"
+	if format != "" {
+		cmd += " -format " + QuoteArg(format)
+	}
+	return cmd + " -- " + QuoteArg(node.Path)
+}
+
+// QuoteArg single-quotes an argument for the beacon's command parser, so
+// spaces, quotes and backslashes in it arrive unchanged on any platform.
+func QuoteArg(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// Refreshing records that taskID is the ls queued by RefreshCommand.
+func (b *Browser) Refreshing(taskID, sessionID, path string, depth int) {
+	b.Lock()
+	defer b.Unlock()
+	b.refreshes[taskID] = refresh{sessionID: sessionID, path: path, depth: depth}
+}
+
+// Forget drops a refresh whose task was cancelled before it ran.
+func (b *Browser) Forget(taskID string) {
+	b.Lock()
+	defer b.Unlock()
+	delete(b.refreshes, taskID)
+}
+
+// Result updates the session's tree from a task result if it was a refresh or
+// another ls -json, and ignores it otherwise.
+func (b *Browser) Result(sessionID, taskID, command, stdout, stderr string) {
+	b.Lock()
+	r, refreshed := b.refreshes[taskID]
+	delete(b.refreshes, taskID)
+	b.Unlock()
+	if !refreshed && !isListing(command) {
+		return
+	}
+
+	tree := b.tree(sessionID)
+	var listing rbresult.Listing
+	if err := json.Unmarshal([]byte(stdout), &listing); err != nil || listing.Path == "" {
+		if refreshed && r.path != "" {
+			tree.Fail(r.path, failure(stderr))
+		}
+		return
+	}
+
+	// Listing a file reports its directory with just that entry, and an
+	// operator's ls may be filtered, so only a refresh that listed the
+	// directory it asked for replaces the cache
+	if !refreshed || (r.path != "" && Clean(r.path) != Clean(listing.Path)) {
+		tree.Merge(listing)
+		return
+	}
+	tree.Replace(listing.Path, listing, r.depth, readFailures(stderr), time.Now())
+}
+
+// isListing reports whether an operator's command is an ls with JSON output.
+func isListing(command string) bool {
+	fields := strings.Fields(command)
+	if len(fields) == 0 || fields[0] != "ls" {
+		return false
+	}
+	for _, field := range fields[1:] {
+		switch strings.TrimLeft(field, "-") {
+		case "json", "json=true":
+			return true
+		}
+	}
+	return false
+}
+
+// readFailures picks the warnings about directories ls could not read, each
+// left as "<path>: <error>".
+func readFailures(stderr string) []string {
+	failures := make([]string, 0)
+	for _, line := range strings.Split(stderr, "\n") {
+		if rest, ok := strings.CutPrefix(line, "failed to read directory "); ok {
+			failures = append(failures, rest)
+		}
+	}
+	return failures
+}
+
+func failure(stderr string) string {
+	if stderr = strings.TrimSpace(stderr); stderr == "" {
+		return "ls returned no listing"
+	}
+	return stderr
+}
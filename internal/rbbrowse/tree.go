@@ -0,0 +1,274 @@
+package rbbrowse
+
+import (
+	"errors"
+	"redbull/internal/rbresult"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrPathNotFound = errors.New("path not listed yet")
+
+// Node is a remote file or directory as last seen. Directories carry the
+// time they were last listed, and their children down to the requested depth.
+type Node struct {
+	rbresult.File
+	ListedAt  *time.Time `json:"listedAt,omitempty"`
+	Truncated bool       `json:"truncated,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	Children  []Node     `json:"children,omitempty"`
+}
+
+// DirSummary describes a cached directory without its contents.
+type DirSummary struct {
+	Path      string     `json:"path"`
+	Entries   int        `json:"entries"`
+	ListedAt  *time.Time `json:"listedAt,omitempty"`
+	Truncated bool       `json:"truncated,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+type dir struct {
+	entries map[string]rbresult.File
+	// listedAt is zero for directories only known from other listings
+	listedAt  time.Time
+	truncated bool
+	err       string
+}
+
+func newDir() *dir {
+	return &dir{entries: make(map[string]rbresult.File)}
+}
+
+// Tree is the cached view of one session's filesystem, keyed by directory
+// path. Paths are kept as the beacon reports them, so Windows and Unix
+// beacons both work.
+type Tree struct {
+	dirs map[string]*dir
+	sync.Mutex
+}
+
+func NewTree() *Tree {
+	return &Tree{dirs: make(map[string]*dir)}
+}
+
+// Replace stores a listing of root that is complete down to depth levels of
+// subdirectories (0 for none), dropping whatever was cached for the
+// directories it covers. Directories that ls warned it could not read, given
+// as "<path>: <error>", are marked with their error instead.
+func (t *Tree) Replace(root string, listing rbresult.Listing, depth int, failures []string, now time.Time) {
+	t.Lock()
+	defer t.Unlock()
+
+	root = Clean(root)
+	groups := make(map[string][]rbresult.File)
+	levels := map[string]int{root: 0}
+	listed := []string{root}
+	for _, entry := range listing.Entries {
+		parent := parentDir(entry.Path)
+		groups[parent] = append(groups[parent], entry)
+		level := levels[parent] + 1
+		levels[entry.Path] = level
+		if entry.Type == "dir" && level <= depth {
+			listed = append(listed, entry.Path)
+		}
+	}
+
+	for _, path := range listed {
+		if err, failed := readFailure(failures, path); failed {
+			t.dir(path).err = err
+			continue
+		}
+		t.replaceDir(path, groups[path], now)
+	}
+	if listing.Truncated {
+		// The listing stopped part way, so the deeper directories it reached
+		// may be missing entries too
+		t.dir(root).truncated = true
+	}
+}
+
+func readFailure(failures []string, path string) (string, bool) {
+	for _, failure := range failures {
+		if err, ok := strings.CutPrefix(failure, path+": "); ok {
+			return err, true
+		}
+	}
+	return "", false
+}
+
+func (t *Tree) replaceDir(path string, entries []rbresult.File, now time.Time) {
+	d := t.dir(path)
+	fresh := make(map[string]rbresult.File, len(entries))
+	for _, entry := range entries {
+		fresh[entry.Name] = entry
+	}
+	for name, old := range d.entries {
+		if _, ok := fresh[name]; !ok && old.Type == "dir" {
+			t.remove(old.Path)
+		}
+	}
+	d.entries = fresh
+	d.listedAt = now
+	d.truncated = false
+	d.err = ""
+}
+
+// remove forgets a directory and everything cached beneath it.
+func (t *Tree) remove(path string) {
+	d, ok := t.dirs[path]
+	if !ok {
+		return
+	}
+	delete(t.dirs, path)
+	for _, entry := range d.entries {
+		if entry.Type == "dir" {
+			t.remove(entry.Path)
+		}
+	}
+}
+
+// Merge adds the entries of a listing that may be partial, such as a filtered
+// ls, without dropping anything already cached.
+func (t *Tree) Merge(listing rbresult.Listing) {
+	t.Lock()
+	defer t.Unlock()
+
+	for _, entry := range listing.Entries {
+		t.dir(parentDir(entry.Path)).entries[entry.Name] = entry
+	}
+}
+
+// Fail records why a directory could not be listed.
+func (t *Tree) Fail(path, err string) {
+	t.Lock()
+	defer t.Unlock()
+	t.dir(Clean(path)).err = err
+}
+
+func (t *Tree) dir(path string) *dir {
+	d, ok := t.dirs[path]
+	if !ok {
+		d = newDir()
+		t.dirs[path] = d
+	}
+	return d
+}
+
+// Node returns the cached view of path with depth levels of children.
+func (t *Tree) Node(path string, depth int) (Node, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	path = Clean(path)
+	entry, known := t.entry(path)
+	_, isDir := t.dirs[path]
+	if !known && !isDir {
+		return Node{}, ErrPathNotFound
+	}
+	if !known {
+		entry = rbresult.File{Name: baseName(path), Path: path, Type: "dir"}
+	}
+	return t.node(entry, depth), nil
+}
+
+// entry finds path among the entries of its parent directory.
+func (t *Tree) entry(path string) (rbresult.File, bool) {
+	parent := parentDir(path)
+	if parent == path {
+		return rbresult.File{}, false
+	}
+	d, ok := t.dirs[parent]
+	if !ok {
+		return rbresult.File{}, false
+	}
+	entry, ok := d.entries[baseName(path)]
+	return entry, ok
+}
+
+func (t *Tree) node(entry rbresult.File, depth int) Node {
+	node := Node{File: entry}
+	d, ok := t.dirs[entry.Path]
+	if !ok {
+		return node
+	}
+
+	node.ListedAt = listedAt(d)
+	node.Truncated = d.truncated
+	node.Error = d.err
+	if depth <= 0 {
+		return node
+	}
+	node.Children = make([]Node, 0, len(d.entries))
+	for _, child := range d.entries {
+		node.Children = append(node.Children, t.node(child, depth-1))
+	}
+	sort.Slice(node.Children, func(i, j int) bool {
+		return node.Children[i].Name < node.Children[j].Name
+	})
+	return node
+}
+
+// Dirs summarises every cached directory, sorted by path.
+func (t *Tree) Dirs() []DirSummary {
+	t.Lock()
+	defer t.Unlock()
+
+	summaries := make([]DirSummary, 0, len(t.dirs))
+	for path, d := range t.dirs {
+		summaries = append(summaries, DirSummary{
+			Path:      path,
+			Entries:   len(d.entries),
+			ListedAt:  listedAt(d),
+			Truncated: d.truncated,
+			Error:     d.err,
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Path < summaries[j].Path
+	})
+	return summaries
+}
+
+func listedAt(d *dir) *time.Time {
+	if d.listedAt.IsZero() {
+		return nil
+	}
+	listedAt := d.listedAt
+	return &listedAt
+}
+
+// Clean drops trailing separators from a remote path, keeping roots such as
+// "/" and "C:\" intact.
+func Clean(path string) string {
+	for len(path) > 1 && strings.ContainsAny(path[len(path)-1:], `/\`) && !isVolumeRoot(path) {
+		path = path[:len(path)-1]
+	}
+	return path
+}
+
+// parentDir returns the directory containing path, or path itself for a
+// root. Both separators are accepted since the beacon may run on Windows.
+func parentDir(path string) string {
+	i := strings.LastIndexAny(path, `/\`)
+	switch {
+	case i < 0:
+		return ""
+	case i == 0:
+		return path[:1]
+	case i == 2 && path[1] == ':':
+		return path[:3]
+	default:
+		return path[:i]
+	}
+}
+
+func baseName(path string) string {
+	return path[strings.LastIndexAny(path, `/\`)+1:]
+}
+
+func isVolumeRoot(path string) bool {
+	return len(path) == 3 && path[1] == ':' && (path[2] == '\\' || path[2] == '/')
+}
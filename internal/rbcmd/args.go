@@ -0,0 +1,112 @@
+package rbcmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// splitArgs splits a command line into arguments on whitespace. Single or
+// double quotes group an argument containing spaces, and a backslash escapes
+// the next character outside single quotes. On Windows a backslash only
+// escapes a quote, so paths like C:\Users and \\server\share need no quoting.
+func splitArgs(cmd string) ([]string, error) {
+	args := make([]string, 0)
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+
+	runes := []rune(cmd)
+	for i, r := range runes {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'' && escapes(runes[i+1:]):
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, errors.New("trailing backslash")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// escapes reports whether a backslash followed by rest is an escape rather
+// than a literal backslash.
+func escapes(rest []rune) bool {
+	if runtime.GOOS != "windows" {
+		return true
+	}
+	return len(rest) > 0 && (rest[0] == '"' || rest[0] == '\'')
+}
+
+// parseArgs splits cmd and parses it with flags, returning the positional
+// arguments. Unlike flag.Parse, flags may come after positional arguments,
+// and "--" ends flag parsing.
+func parseArgs(flags *flag.FlagSet, cmd string) ([]string, error) {
+	args, err := splitArgs(cmd)
+	if err != nil {
+		return nil, err
+	}
+	flags.SetOutput(io.Discard)
+
+	positional := make([]string, 0)
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, fmt.Errorf("%s: %w", flags.Name(), err)
+		}
+		rest := flags.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// resolvePath makes path absolute, resolving relative paths against the
+// beacon's tracked working directory rather than the process's.
+func resolvePath(ctx *Context, path string) string {
+	if path == "" {
+		return ctx.CWD.Get()
+	}
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(ctx.CWD.Get(), path)
+}
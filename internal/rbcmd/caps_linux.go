@@ -0,0 +1,56 @@
+package rbcmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// capabilityNames are indexed by capability number, as in linux/capability.h.
+var capabilityNames = []string{
+	"chown", "dac_override", "dac_read_search", "fowner", "fsetid", "kill",
+	"setgid", "setuid", "setpcap", "linux_immutable", "net_bind_service",
+	"net_broadcast", "net_admin", "net_raw", "ipc_lock", "ipc_owner",
+	"sys_module", "sys_rawio", "sys_chroot", "sys_ptrace", "sys_pacct",
+	"sys_admin", "sys_boot", "sys_nice", "sys_resource", "sys_time",
+	"sys_tty_config", "mknod", "lease", "audit_write", "audit_control",
+	"setfcap", "mac_override", "mac_admin", "syslog", "wake_alarm",
+	"block_suspend", "audit_read", "perfmon", "bpf", "checkpoint_restore",
+}
+
+// effectiveCapabilities decodes the CapEff mask of the beacon process.
+// Capabilities newer than this list are reported by number.
+func effectiveCapabilities() []string {
+	file, err := os.Open("/proc/self/status")
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		rest, ok := strings.CutPrefix(scanner.Text(), "CapEff:")
+		if !ok {
+			continue
+		}
+		mask, err := strconv.ParseUint(strings.TrimSpace(rest), 16, 64)
+		if err != nil {
+			return nil
+		}
+		caps := make([]string, 0)
+		for bit := 0; bit < 64; bit++ {
+			if mask&(1<<bit) == 0 {
+				continue
+			}
+			if bit < len(capabilityNames) {
+				caps = append(caps, "cap_"+capabilityNames[bit])
+			} else {
+				caps = append(caps, fmt.Sprintf("cap_%d", bit))
+			}
+		}
+		return caps
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+//go:build unix && !linux
+
+package rbcmd
+
+func effectiveCapabilities() []string {
+	return nil
+}
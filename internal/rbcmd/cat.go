@@ -0,0 +1,159 @@
+package rbcmd
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// maxCatBytes caps how much of a file cat returns in one task; use -offset
+// to page through larger files.
+const maxCatBytes = 1 << 20
+
+type CatCommand struct{}
+
+// CatResult is the structured result of cat -json.
+type CatResult struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	Offset    int64  `json:"offset"`
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+func (c *CatCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	flags := flag.NewFlagSet("cat", flag.ContinueOnError)
+	offset := flags.Int64("offset", 0, "byte to start at")
+	length := flags.Int64("length", maxCatBytes, "most bytes to read")
+	head := flags.Int("head", 0, "only the first n lines")
+	tail := flags.Int("tail", 0, "only the last n lines")
+	asJSON := flags.Bool("json", false, "structured JSON result")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+	if len(args) != 1 {
+		return "", "", errors.New("expected a path")
+	}
+	if *offset < 0 || *head < 0 || *tail < 0 {
+		return "", "", errors.New("offset, head and tail cannot be negative")
+	}
+	if *length < 1 {
+		return "", "", errors.New("length must be at least 1")
+	}
+	if *head > 0 && *tail > 0 {
+		return "", "", errors.New("use either head or tail, not both")
+	}
+
+	path := resolvePath(ctx, args[0])
+	file, err := os.Open(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return "", "", fmt.Errorf("%s is a directory", path)
+	}
+
+	result := CatResult{Path: path, Size: info.Size(), Offset: *offset}
+	limit := min(*length, maxCatBytes)
+	switch {
+	case *head > 0:
+		result.Content, result.Truncated, err = headLines(file, *head, limit)
+	case *tail > 0:
+		result.Content, result.Offset, result.Truncated, err = tailLines(file, info.Size(), *tail, limit)
+	default:
+		result.Content, result.Truncated, err = readRange(file, *offset, limit, info.Size())
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if *asJSON {
+		out, err := toJSON(result)
+		return out, "", err
+	}
+	stderr := ""
+	if result.Truncated && limit == maxCatBytes {
+		stderr = fmt.Sprintf("output truncated at %d bytes of %d; use -offset to read further", limit, result.Size)
+	}
+	return result.Content, stderr, nil
+}
+
+func readRange(file *os.File, offset, limit, size int64) (string, bool, error) {
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return "", false, err
+	}
+	data, err := io.ReadAll(io.LimitReader(file, limit))
+	if err != nil {
+		return "", false, err
+	}
+	return string(data), offset+int64(len(data)) < size && int64(len(data)) == limit, nil
+}
+
+func headLines(file *os.File, lines int, limit int64) (string, bool, error) {
+	var b strings.Builder
+	reader := bufio.NewReader(io.LimitReader(file, limit))
+	for i := 0; i < lines; i++ {
+		line, err := reader.ReadString('\n')
+		b.WriteString(line)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", false, err
+		}
+	}
+	return b.String(), int64(b.Len()) == limit, nil
+}
+
+// tailLines reads backwards from the end of the file in blocks until it has
+// seen enough newlines, so large files are not read in full.
+func tailLines(file *os.File, size int64, lines int, limit int64) (string, int64, bool, error) {
+	const blockSize = 32 * 1024
+
+	start := size
+	newlines := 0
+	block := make([]byte, blockSize)
+	for start > 0 && size-start < limit {
+		n := min(int64(blockSize), start)
+		start -= n
+		if _, err := file.ReadAt(block[:n], start); err != nil && !errors.Is(err, io.EOF) {
+			return "", 0, false, err
+		}
+		for i := n - 1; i >= 0; i-- {
+			// A trailing newline ends the last line rather than starting a new one
+			if block[i] != '\n' || start+i == size-1 {
+				continue
+			}
+			newlines++
+			if newlines == lines {
+				start += i + 1
+				return readTail(file, start, size, limit)
+			}
+		}
+	}
+	return readTail(file, start, size, limit)
+}
+
+func readTail(file *os.File, start, size, limit int64) (string, int64, bool, error) {
+	truncated := false
+	if size-start > limit {
+		start = size - limit
+		truncated = true
+	}
+	data := make([]byte, size-start)
+	if _, err := file.ReadAt(data, start); err != nil && !errors.Is(err, io.EOF) {
+		return "", 0, false, err
+	}
+	return string(data), start, truncated, nil
+}
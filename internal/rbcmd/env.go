@@ -0,0 +1,87 @@
+package rbcmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+type EnvCommand struct{}
+
+// EnvVar is an environment variable in the structured result of env -json.
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// Set is false when get or unset found no such variable
+	Set bool `json:"set"`
+}
+
+func (c *EnvCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	flags := flag.NewFlagSet("env", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "structured JSON result")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+
+	var vars []EnvVar
+	switch {
+	case len(args) == 0:
+		vars = environment()
+	case args[0] == "get" && len(args) == 2:
+		value, ok := os.LookupEnv(args[1])
+		vars = []EnvVar{{Name: args[1], Value: value, Set: ok}}
+	case args[0] == "set" && len(args) == 2:
+		name, value, ok := strings.Cut(args[1], "=")
+		if !ok || name == "" {
+			return "", "", fmt.Errorf("expected <name>=<value>, got '%s'", args[1])
+		}
+		if err := os.Setenv(name, value); err != nil {
+			return "", "", fmt.Errorf("failed to set %s: %w", name, err)
+		}
+		vars = []EnvVar{{Name: name, Value: value, Set: true}}
+	case args[0] == "unset" && len(args) == 2:
+		_, ok := os.LookupEnv(args[1])
+		if err := os.Unsetenv(args[1]); err != nil {
+			return "", "", fmt.Errorf("failed to unset %s: %w", args[1], err)
+		}
+		vars = []EnvVar{{Name: args[1], Set: ok}}
+	default:
+		return "", "", fmt.Errorf("expected get <name>, set <name>=<value> or unset <name>")
+	}
+
+	if *asJSON {
+		out, err := toJSON(vars)
+		return out, "", err
+	}
+	if len(args) > 0 && args[0] == "unset" {
+		return "unset " + args[1], "", nil
+	}
+	if len(args) > 0 && !vars[0].Set {
+		return "", "", fmt.Errorf("%s is not set", args[1])
+	}
+	lines := make([]string, 0, len(vars))
+	for _, v := range vars {
+		lines = append(lines, v.Name+"="+v.Value)
+	}
+	return strings.Join(lines, "\n"), "", nil
+}
+
+// environment lists the beacon's environment sorted by name.
+func environment() []EnvVar {
+	vars := make([]EnvVar, 0)
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		// Windows keeps per-drive working directories in variables like =C:
+		if name == "" {
+			continue
+		}
+		vars = append(vars, EnvVar{Name: name, Value: value, Set: true})
+	}
+	sort.Slice(vars, func(i, j int) bool {
+		return vars[i].Name < vars[j].Name
+	})
+	return vars
+}
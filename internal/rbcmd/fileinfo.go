@@ -0,0 +1,92 @@
+package rbcmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"redbull/internal/rbresult"
+	"strings"
+	"text/tabwriter"
+)
+
+// newFileEntry describes the file at path without following symlinks.
+func newFileEntry(path string, info fs.FileInfo) rbresult.File {
+	owner, group := fileOwner(info)
+	entry := rbresult.File{
+		Name:    info.Name(),
+		Path:    path,
+		Type:    fileType(info.Mode()),
+		Mode:    info.Mode().String(),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Owner:   owner,
+		Group:   group,
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		entry.LinkTarget, _ = os.Readlink(path)
+	}
+	return entry
+}
+
+func fileType(mode fs.FileMode) string {
+	switch {
+	case mode.IsDir():
+		return "dir"
+	case mode&fs.ModeSymlink != 0:
+		return "symlink"
+	case mode.IsRegular():
+		return "file"
+	default:
+		return "other"
+	}
+}
+
+// formatLong renders entries as ls -l style lines, showing each entry's path
+// relative to base.
+func formatLong(entries []rbresult.File, base string) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 1, ' ', tabwriter.AlignRight)
+	for _, entry := range entries {
+		name := relativeName(entry, base)
+		if entry.LinkTarget != "" {
+			name += " -> " + entry.LinkTarget
+		}
+		fmt.Fprintf(w, "%s\t %s\t %s\t %d\t %s\t %s\n", entry.Mode, dashIfEmpty(entry.Owner), dashIfEmpty(entry.Group), entry.Size, entry.ModTime.Format("2006-01-02 15:04"), name)
+	}
+	w.Flush()
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// formatNames renders entries one per line, relative to base.
+func formatNames(entries []rbresult.File, base string) string {
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, relativeName(entry, base))
+	}
+	return strings.Join(names, "\n")
+}
+
+func relativeName(entry rbresult.File, base string) string {
+	if rel, err := filepath.Rel(base, entry.Path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return entry.Path
+}
+
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// toJSON renders a structured result for the server.
+func toJSON(v any) (string, error) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,181 @@
+package rbcmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"redbull/internal/rbresult"
+	"strings"
+	"time"
+)
+
+type FindCommand struct{}
+
+type findOptions struct {
+	pattern  string
+	fileType string
+	minSize  int64
+	maxSize  int64
+	newer    time.Time
+	older    time.Time
+	maxDepth int
+}
+
+func (c *FindCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	var opts findOptions
+	flags := flag.NewFlagSet("find", flag.ContinueOnError)
+	flags.StringVar(&opts.pattern, "name", "", "only files whose name matches the glob")
+	flags.StringVar(&opts.fileType, "type", "", "only files (f), directories (d) or symlinks (l)")
+	minSize := flags.String("min-size", "", "only files at least this big")
+	maxSize := flags.String("max-size", "", "only files at most this big")
+	newer := flags.String("newer", "", "only files modified within this long, such as 2h or 7d")
+	older := flags.String("older", "", "only files last modified longer ago than this")
+	flags.IntVar(&opts.maxDepth, "maxdepth", 0, "how many levels to descend, 0 for no limit")
+	limit := flags.Int("limit", maxLsEntries, "most files to list")
+	long := flags.Bool("l", false, "long format")
+	asJSON := flags.Bool("json", false, "structured JSON result")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+	if len(args) > 1 {
+		return "", "", fmt.Errorf("expected at most one path, got %d", len(args))
+	}
+	if *limit < 1 {
+		return "", "", errors.New("limit must be at least 1")
+	}
+	if _, err := filepath.Match(opts.pattern, ""); err != nil {
+		return "", "", fmt.Errorf("invalid pattern '%s': %w", opts.pattern, err)
+	}
+	if opts.fileType != "" && opts.fileType != "f" && opts.fileType != "d" && opts.fileType != "l" {
+		return "", "", fmt.Errorf("invalid type '%s': expected f, d or l", opts.fileType)
+	}
+
+	opts.maxSize = -1
+	if *minSize != "" {
+		if opts.minSize, err = parseSize(*minSize); err != nil {
+			return "", "", err
+		}
+	}
+	if *maxSize != "" {
+		if opts.maxSize, err = parseSize(*maxSize); err != nil {
+			return "", "", err
+		}
+	}
+	now := time.Now()
+	if *newer != "" {
+		age, err := parseAge(*newer)
+		if err != nil {
+			return "", "", err
+		}
+		opts.newer = now.Add(-age)
+	}
+	if *older != "" {
+		age, err := parseAge(*older)
+		if err != nil {
+			return "", "", err
+		}
+		opts.older = now.Add(-age)
+	}
+
+	root := ""
+	if len(args) == 1 {
+		root = args[0]
+	}
+	root = resolvePath(ctx, root)
+
+	result := rbresult.Listing{Path: root, Entries: make([]rbresult.File, 0)}
+	warnings := make([]string, 0)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			warnings = append(warnings, err.Error())
+			return nil
+		}
+		if err := ctx.Ctx.Err(); err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		depth := strings.Count(rel, string(filepath.Separator)) + 1
+
+		info, err := d.Info()
+		if err != nil {
+			warnings = append(warnings, err.Error())
+			return nil
+		}
+		entry := newFileEntry(path, info)
+		if opts.matches(entry) {
+			if len(result.Entries) == *limit {
+				result.Truncated = true
+				return fs.SkipAll
+			}
+			result.Entries = append(result.Entries, entry)
+		}
+
+		if d.IsDir() && opts.maxDepth > 0 && depth >= opts.maxDepth {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to search %s: %w", root, err)
+	}
+
+	var stdout string
+	switch {
+	case *asJSON:
+		stdout, err = toJSON(result)
+		if err != nil {
+			return "", "", err
+		}
+	case *long:
+		stdout = formatLong(result.Entries, root)
+	default:
+		stdout = formatNames(result.Entries, root)
+	}
+	if result.Truncated {
+		warnings = append(warnings, fmt.Sprintf("stopped after %d files", *limit))
+	}
+	return stdout, strings.Join(warnings, "\n"), nil
+}
+
+func (o findOptions) matches(entry rbresult.File) bool {
+	if o.pattern != "" && !matches(o.pattern, entry.Name) {
+		return false
+	}
+	switch o.fileType {
+	case "f":
+		if entry.Type != "file" {
+			return false
+		}
+	case "d":
+		if entry.Type != "dir" {
+			return false
+		}
+	case "l":
+		if entry.Type != "symlink" {
+			return false
+		}
+	}
+	if entry.Size < o.minSize || (o.maxSize >= 0 && entry.Size > o.maxSize) {
+		return false
+	}
+	if !o.newer.IsZero() && entry.ModTime.Before(o.newer) {
+		return false
+	}
+	if !o.older.IsZero() && entry.ModTime.After(o.older) {
+		return false
+	}
+	return true
+}
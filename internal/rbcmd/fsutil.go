@@ -0,0 +1,234 @@
+package rbcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChangeResult is the structured result of commands that change the
+// filesystem, listing the paths they touched.
+type ChangeResult struct {
+	Op    string   `json:"op"`
+	Paths []string `json:"paths"`
+}
+
+// changeOutput renders a ChangeResult as JSON or as one line per path.
+func changeOutput(asJSON bool, op string, paths []string) (string, error) {
+	if asJSON {
+		return toJSON(ChangeResult{Op: op, Paths: paths})
+	}
+	lines := make([]string, 0, len(paths))
+	for _, path := range paths {
+		lines = append(lines, fmt.Sprintf("%s %s", op, path))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// parseSize parses a byte count with an optional K, M or G suffix (powers of
+// 1024).
+func parseSize(s string) (int64, error) {
+	multiplier := int64(1)
+	switch strings.ToUpper(s[len(s)-min(len(s), 1):]) {
+	case "K":
+		multiplier = 1 << 10
+	case "M":
+		multiplier = 1 << 20
+	case "G":
+		multiplier = 1 << 30
+	}
+	if multiplier > 1 {
+		s = s[:len(s)-1]
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size '%s'", s)
+	}
+	return n * multiplier, nil
+}
+
+// parseAge parses a duration as time.ParseDuration does, also accepting a
+// number of days such as "7d".
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age '%s'", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age '%s'", s)
+	}
+	return d, nil
+}
+
+// copyPath copies a file, symlink or, when recursive, a directory tree from
+// src to dst, keeping permissions and modification times. It returns the
+// paths it created.
+func copyPath(ctx context.Context, src, dst string, recursive bool) ([]string, error) {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() && !recursive {
+		return nil, fmt.Errorf("%s is a directory (use -r)", src)
+	}
+	if info.IsDir() && isWithin(realPath(dst), realPath(src)) {
+		return nil, fmt.Errorf("cannot copy %s into itself", src)
+	}
+	if err := notSameFile(src, dst); err != nil {
+		return nil, err
+	}
+
+	created := make([]string, 0)
+	// Copying into a directory changes its modification time, so directories
+	// get theirs once everything is copied
+	dirTimes := make(map[string]time.Time)
+	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			if err := os.MkdirAll(target, info.Mode().Perm()); err != nil {
+				return err
+			}
+			created = append(created, target)
+			dirTimes[target] = info.ModTime()
+			return nil
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(link, target); err != nil {
+				return err
+			}
+			created = append(created, target)
+			return nil
+		case info.Mode().IsRegular():
+			// A link in an existing destination tree can lead back into src
+			if err := notSameFile(path, target); err != nil {
+				return err
+			}
+			if err := copyFile(path, target, info.Mode().Perm()); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("cannot copy %s: not a regular file", path)
+		}
+
+		created = append(created, target)
+		return os.Chtimes(target, info.ModTime(), info.ModTime())
+	})
+	if err != nil {
+		return created, err
+	}
+
+	for dir, modTime := range dirTimes {
+		if err := os.Chtimes(dir, modTime, modTime); err != nil {
+			return created, err
+		}
+	}
+	return created, nil
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// notSameFile fails if dst already exists and is src, whether by the same
+// name, a link or a hard link, since opening it to copy into would truncate
+// src before it was read.
+func notSameFile(src, dst string) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return nil
+	}
+	dstInfo, err := os.Stat(dst)
+	if err != nil {
+		return nil
+	}
+	if os.SameFile(srcInfo, dstInfo) {
+		return fmt.Errorf("%s and %s are the same file", src, dst)
+	}
+	return nil
+}
+
+// realPath resolves the symlinks in path, which need not exist yet: the part
+// that does is resolved and the rest joined back on.
+func realPath(path string) string {
+	rest := ""
+	for {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return filepath.Join(path, rest)
+		}
+		rest = filepath.Join(filepath.Base(path), rest)
+		path = parent
+	}
+}
+
+// isWithin reports whether path is dir or inside it.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// destination returns where src ends up when copied or moved to dst: inside
+// dst if it is an existing directory, or dst itself otherwise. Several
+// sources need a directory to go into.
+func destination(src, dst string, sources int) (string, error) {
+	info, err := os.Stat(dst)
+	if err == nil && info.IsDir() {
+		return filepath.Join(dst, filepath.Base(src)), nil
+	}
+	if sources > 1 {
+		return "", fmt.Errorf("%s is not a directory", dst)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	return dst, nil
+}
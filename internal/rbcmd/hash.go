@@ -0,0 +1,82 @@
+package rbcmd
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+var hashes = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+}
+
+type HashCommand struct{}
+
+// HashResult is one file in the structured result of hash -json.
+type HashResult struct {
+	Path      string `json:"path"`
+	Algorithm string `json:"algorithm"`
+	Hash      string `json:"hash"`
+	Size      int64  `json:"size"`
+}
+
+func (c *HashCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	flags := flag.NewFlagSet("hash", flag.ContinueOnError)
+	algo := flags.String("algo", "sha256", "md5, sha1 or sha256")
+	asJSON := flags.Bool("json", false, "structured JSON result")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+	if len(args) == 0 {
+		return "", "", errors.New("expected at least one path")
+	}
+	newHash, ok := hashes[*algo]
+	if !ok {
+		return "", "", fmt.Errorf("unknown algorithm '%s': expected md5, sha1 or sha256", *algo)
+	}
+
+	results := make([]HashResult, 0, len(args))
+	for _, arg := range args {
+		path := resolvePath(ctx, arg)
+		sum, size, err := hashFile(path, newHash())
+		if err != nil {
+			return "", "", fmt.Errorf("failed to hash %s: %w", path, err)
+		}
+		results = append(results, HashResult{Path: path, Algorithm: *algo, Hash: sum, Size: size})
+	}
+
+	if *asJSON {
+		out, err := toJSON(results)
+		return out, "", err
+	}
+	lines := make([]string, 0, len(results))
+	for _, result := range results {
+		lines = append(lines, fmt.Sprintf("%s  %s", result.Hash, result.Path))
+	}
+	return strings.Join(lines, "\n"), "", nil
+}
+
+func hashFile(path string, h hash.Hash) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
@@ -2,19 +2,16 @@ package rbcmd
 
 import (
 	"fmt"
+	"redbull/internal/rbusage"
 )
 
 type HelpCommand struct{}
 
-func (c *HelpCommand) Help() string {
-	return "Display help information: help"
-}
-
 func (c *HelpCommand) Execute(ctx *Context, cmd string) (string, string, error) {
 	help := "Available commands:\n"
 	registry := GetRegistry()
-	for name, command := range registry {
-		help += fmt.Sprintf("  %s - %s\n", name, command.Help())
+	for name := range registry {
+		help += fmt.Sprintf("  %s - %s\n", name, rbusage.Commands[name])
 	}
 	return help, "", nil
 }
@@ -0,0 +1,97 @@
+package rbcmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+type HostinfoCommand struct{}
+
+// HostInfo is the structured result of hostinfo -json. Fields the platform
+// does not expose are left empty.
+type HostInfo struct {
+	Hostname        string    `json:"hostname"`
+	OS              string    `json:"os"`
+	Arch            string    `json:"arch"`
+	Release         string    `json:"release,omitempty"`
+	Kernel          string    `json:"kernel,omitempty"`
+	BootTime        time.Time `json:"bootTime,omitzero"`
+	UptimeSeconds   int64     `json:"uptimeSeconds,omitempty"`
+	CPUs            int       `json:"cpus"`
+	CPUModel        string    `json:"cpuModel,omitempty"`
+	MemoryTotal     int64     `json:"memoryTotal,omitempty"`
+	MemoryAvailable int64     `json:"memoryAvailable,omitempty"`
+	LoadAverage     []float64 `json:"loadAverage,omitempty"`
+}
+
+func (c *HostinfoCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	flags := flag.NewFlagSet("hostinfo", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "structured JSON result")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+	if len(args) > 0 {
+		return "", "", fmt.Errorf("unexpected argument '%s'", args[0])
+	}
+
+	info := HostInfo{OS: runtime.GOOS, Arch: runtime.GOARCH, CPUs: runtime.NumCPU()}
+	if ctx.Host != nil {
+		info.Hostname, info.Kernel = ctx.Host.Hostname, ctx.Host.Kernel
+	} else if hostname, err := os.Hostname(); err == nil {
+		info.Hostname = hostname
+	}
+	collectSystemInfo(&info)
+	if !info.BootTime.IsZero() {
+		info.UptimeSeconds = int64(time.Since(info.BootTime).Seconds())
+	}
+
+	if *asJSON {
+		out, err := toJSON(info)
+		return out, "", err
+	}
+	return formatHostInfo(info), "", nil
+}
+
+func formatHostInfo(info HostInfo) string {
+	lines := []string{
+		"Hostname: " + info.Hostname,
+		fmt.Sprintf("OS/Arch: %s/%s", info.OS, info.Arch),
+		"Release: " + dashIfEmpty(info.Release),
+		"Kernel: " + dashIfEmpty(info.Kernel),
+	}
+	if !info.BootTime.IsZero() {
+		uptime := time.Duration(info.UptimeSeconds) * time.Second
+		lines = append(lines, fmt.Sprintf("Uptime: %s (since %s)", uptime, info.BootTime.Format(time.RFC3339)))
+	}
+	cpus := fmt.Sprintf("CPUs: %d", info.CPUs)
+	if info.CPUModel != "" {
+		cpus += " x " + info.CPUModel
+	}
+	lines = append(lines, cpus)
+	if info.MemoryTotal > 0 {
+		lines = append(lines, fmt.Sprintf("Memory: %s total, %s available", formatBytes(info.MemoryTotal), formatBytes(info.MemoryAvailable)))
+	}
+	if len(info.LoadAverage) == 3 {
+		lines = append(lines, fmt.Sprintf("Load: %.2f %.2f %.2f", info.LoadAverage[0], info.LoadAverage[1], info.LoadAverage[2]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatBytes renders a byte count in the largest whole binary unit.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
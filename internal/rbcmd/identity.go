@@ -0,0 +1,74 @@
+package rbcmd
+
+import (
+	"flag"
+	"fmt"
+	"redbull/internal/rbresult"
+	"strings"
+)
+
+type WhoamiCommand struct{}
+
+func (c *WhoamiCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	return identityCommand("whoami", cmd, func(id rbresult.Identity) string { return id.User })
+}
+
+type IdCommand struct{}
+
+func (c *IdCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	return identityCommand("id", cmd, formatIdentity)
+}
+
+// identityCommand runs whoami or id, which differ only in their text output.
+func identityCommand(name, cmd string, format func(rbresult.Identity) string) (string, string, error) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "structured JSON result")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+	if len(args) > 0 {
+		return "", "", fmt.Errorf("unexpected argument '%s'", args[0])
+	}
+
+	id, err := currentIdentity()
+	if err != nil {
+		return "", "", err
+	}
+	if *asJSON {
+		out, err := toJSON(id)
+		return out, "", err
+	}
+	return format(id), "", nil
+}
+
+func formatIdentity(id rbresult.Identity) string {
+	line := fmt.Sprintf("uid=%s(%s)", id.UID, id.User)
+	if id.GID != "" {
+		line += fmt.Sprintf(" gid=%s(%s)", id.GID, groupName(id, id.GID))
+	}
+	if id.EUID != "" && id.EUID != id.UID {
+		line += " euid=" + id.EUID
+	}
+	if id.EGID != "" && id.EGID != id.GID {
+		line += " egid=" + id.EGID
+	}
+	groups := make([]string, 0, len(id.Groups))
+	for _, g := range id.Groups {
+		groups = append(groups, fmt.Sprintf("%s(%s)", g.ID, g.Name))
+	}
+	line += " groups=" + strings.Join(groups, ",")
+	if len(id.Capabilities) > 0 {
+		line += "\ncapabilities=" + strings.Join(id.Capabilities, ",")
+	}
+	return line
+}
+
+func groupName(id rbresult.Identity, gid string) string {
+	for _, g := range id.Groups {
+		if g.ID == gid {
+			return g.Name
+		}
+	}
+	return gid
+}
@@ -0,0 +1,30 @@
+//go:build !unix
+
+package rbcmd
+
+import (
+	"fmt"
+	"os/user"
+	"redbull/internal/rbresult"
+)
+
+func currentIdentity() (rbresult.Identity, error) {
+	u, err := user.Current()
+	if err != nil {
+		return rbresult.Identity{}, fmt.Errorf("failed to look up current user: %w", err)
+	}
+	id := rbresult.Identity{User: u.Username, UID: u.Uid, GID: u.Gid, Groups: make([]rbresult.Group, 0)}
+
+	gids, err := u.GroupIds()
+	if err != nil {
+		return id, fmt.Errorf("failed to read groups: %w", err)
+	}
+	for _, gid := range gids {
+		name := gid
+		if g, err := user.LookupGroupId(gid); err == nil {
+			name = g.Name
+		}
+		id.Groups = append(id.Groups, rbresult.Group{ID: gid, Name: name})
+	}
+	return id, nil
+}
@@ -0,0 +1,39 @@
+//go:build unix
+
+package rbcmd
+
+import (
+	"fmt"
+	"os"
+	"redbull/internal/rbresult"
+	"strconv"
+)
+
+func currentIdentity() (rbresult.Identity, error) {
+	uid, gid := strconv.Itoa(os.Getuid()), strconv.Itoa(os.Getgid())
+	id := rbresult.Identity{
+		User:         lookupName(&userNames, uid, lookupUser),
+		UID:          uid,
+		GID:          gid,
+		EUID:         strconv.Itoa(os.Geteuid()),
+		EGID:         strconv.Itoa(os.Getegid()),
+		Groups:       make([]rbresult.Group, 0),
+		Capabilities: effectiveCapabilities(),
+	}
+
+	gids, err := os.Getgroups()
+	if err != nil {
+		return id, fmt.Errorf("failed to read groups: %w", err)
+	}
+	seen := false
+	for _, g := range gids {
+		group := strconv.Itoa(g)
+		seen = seen || group == gid
+		id.Groups = append(id.Groups, rbresult.Group{ID: group, Name: lookupName(&groupNames, group, lookupGroup)})
+	}
+	if !seen {
+		// The primary group is not always among the supplementary groups
+		id.Groups = append([]rbresult.Group{{ID: gid, Name: lookupName(&groupNames, gid, lookupGroup)}}, id.Groups...)
+	}
+	return id, nil
+}
@@ -0,0 +1,78 @@
+package rbcmd
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"redbull/internal/rbresult"
+	"strings"
+)
+
+type IfconfigCommand struct{}
+
+func (c *IfconfigCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	flags := flag.NewFlagSet("ifconfig", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "structured JSON result")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+	if len(args) > 1 {
+		return "", "", fmt.Errorf("expected at most one interface, got %d", len(args))
+	}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list interfaces: %w", err)
+	}
+	result := make([]rbresult.Interface, 0, len(ifaces))
+	warnings := make([]string, 0)
+	for _, iface := range ifaces {
+		if len(args) == 1 && iface.Name != args[0] {
+			continue
+		}
+		ni := rbresult.Interface{
+			Name:      iface.Name,
+			Index:     iface.Index,
+			MTU:       iface.MTU,
+			MAC:       iface.HardwareAddr.String(),
+			Flags:     strings.Split(iface.Flags.String(), "|"),
+			Addresses: make([]string, 0),
+		}
+		if iface.Flags == 0 {
+			ni.Flags = make([]string, 0)
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("failed to read addresses of %s: %v", iface.Name, err))
+		}
+		for _, addr := range addrs {
+			ni.Addresses = append(ni.Addresses, addr.String())
+		}
+		result = append(result, ni)
+	}
+	if len(args) == 1 && len(result) == 0 {
+		return "", "", fmt.Errorf("no interface named %s", args[0])
+	}
+
+	if *asJSON {
+		out, err := toJSON(result)
+		return out, strings.Join(warnings, "\n"), err
+	}
+	blocks := make([]string, 0, len(result))
+	for _, ni := range result {
+		block := fmt.Sprintf("%s: index %d mtu %d <%s>", ni.Name, ni.Index, ni.MTU, strings.Join(ni.Flags, ","))
+		if ni.MAC != "" {
+			block += "\n    ether " + ni.MAC
+		}
+		for _, addr := range ni.Addresses {
+			if strings.Contains(addr, ":") {
+				block += "\n    inet6 " + addr
+			} else {
+				block += "\n    inet " + addr
+			}
+		}
+		blocks = append(blocks, block)
+	}
+	return strings.Join(blocks, "\n"), strings.Join(warnings, "\n"), nil
+}
@@ -0,0 +1,60 @@
+package rbcmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+type KillCommand struct{}
+
+// KillResult is the structured result of kill -json.
+type KillResult struct {
+	PID    int    `json:"pid"`
+	Signal string `json:"signal"`
+}
+
+func (c *KillCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	flags := flag.NewFlagSet("kill", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "structured JSON result")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+	if len(args) == 0 || len(args) > 2 {
+		return "", "", errors.New("expected a pid and optionally a signal")
+	}
+
+	pid, err := strconv.Atoi(args[0])
+	if err != nil || pid <= 0 {
+		// Zero and negative pids signal whole process groups
+		return "", "", fmt.Errorf("invalid pid '%s'", args[0])
+	}
+	if pid == os.Getpid() {
+		return "", "", errors.New("refusing to signal the beacon itself")
+	}
+	name := defaultSignal
+	if len(args) == 2 {
+		name = args[1]
+	}
+	sig, name, err := parseSignal(name)
+	if err != nil {
+		return "", "", err
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find process %d: %w", pid, err)
+	}
+	if err := process.Signal(sig); err != nil {
+		return "", "", fmt.Errorf("failed to signal process %d: %w", pid, err)
+	}
+
+	if *asJSON {
+		out, err := toJSON(KillResult{PID: pid, Signal: name})
+		return out, "", err
+	}
+	return fmt.Sprintf("sent %s to %d", name, pid), "", nil
+}
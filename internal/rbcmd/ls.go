@@ -1,31 +1,190 @@
 package rbcmd
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"redbull/internal/rbresult"
+	"sort"
 	"strings"
 )
 
+// maxLsEntries stops a recursive listing of a large tree from producing a
+// result too big to send back in one piece.
+const maxLsEntries = 10000
+
 type LsCommand struct{}
 
-func (c *LsCommand) Help() string {
-	return "List directory contents: ls <path>"
+type lsOptions struct {
+	long      bool
+	recursive bool
+	depth     int
+	pattern   string
+	sortBy    string
+	reverse   bool
+	asJSON    bool
+	limit     int
 }
 
 func (c *LsCommand) Execute(ctx *Context, cmd string) (string, string, error) {
-	path := cmd
-	if path == "" {
-		path = *ctx.CWD
+	var opts lsOptions
+	flags := flag.NewFlagSet("ls", flag.ContinueOnError)
+	flags.BoolVar(&opts.long, "l", false, "long format")
+	flags.BoolVar(&opts.recursive, "R", false, "list subdirectories recursively")
+	flags.IntVar(&opts.depth, "depth", 0, "how many levels of subdirectories to list, 0 for no limit")
+	flags.StringVar(&opts.pattern, "name", "", "only list entries whose name matches the glob")
+	flags.StringVar(&opts.sortBy, "sort", "name", "sort by name, size or time")
+	flags.BoolVar(&opts.reverse, "r", false, "reverse the sort order")
+	flags.IntVar(&opts.limit, "limit", maxLsEntries, "most entries to list")
+	flags.BoolVar(&opts.asJSON, "json", false, "structured JSON result")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+	if len(args) > 1 {
+		return "", "", fmt.Errorf("expected at most one path, got %d", len(args))
+	}
+	if opts.depth > 0 {
+		opts.recursive = true
+	}
+	if opts.limit < 1 {
+		return "", "", errors.New("limit must be at least 1")
 	}
-	files, err := os.ReadDir(path)
+	if opts.sortBy != "name" && opts.sortBy != "size" && opts.sortBy != "time" {
+		return "", "", fmt.Errorf("invalid sort '%s': expected name, size or time", opts.sortBy)
+	}
+
+	path := ""
+	if len(args) == 1 {
+		path = args[0]
+	}
+	path = resolvePath(ctx, path)
+
+	// A glob as the path, like ls *.go, filters its directory
+	if _, err := os.Lstat(path); os.IsNotExist(err) && opts.pattern == "" && strings.ContainsAny(filepath.Base(path), "*?[") {
+		path, opts.pattern = filepath.Dir(path), filepath.Base(path)
+	}
+	if _, err := filepath.Match(opts.pattern, ""); err != nil {
+		return "", "", fmt.Errorf("invalid pattern '%s': %w", opts.pattern, err)
+	}
+
+	result, warnings, err := list(path, opts)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to read directory %s: %w", *ctx.CWD, err)
+		return "", "", err
+	}
+
+	var stdout string
+	switch {
+	case opts.asJSON:
+		stdout, err = toJSON(result)
+		if err != nil {
+			return "", "", err
+		}
+	case opts.long:
+		stdout = formatLong(result.Entries, result.Path)
+	default:
+		stdout = formatNames(result.Entries, result.Path)
+	}
+	if result.Truncated {
+		warnings = append(warnings, fmt.Sprintf("stopped after %d entries", opts.limit))
 	}
+	return stdout, strings.Join(warnings, "\n"), nil
+}
 
-	fileNames := make([]string, 0)
-	for _, f := range files {
-		fileNames = append(fileNames, f.Name())
+// list describes path, or the contents of path if it is a directory. Errors
+// reading subdirectories are returned as warnings rather than failing the
+// whole listing.
+func list(path string, opts lsOptions) (rbresult.Listing, []string, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return rbresult.Listing{}, nil, fmt.Errorf("failed to read %s: %w", path, err)
 	}
 
-	return strings.Join(fileNames, "\n"), "", nil
+	result := rbresult.Listing{Path: path, Entries: make([]rbresult.File, 0)}
+	if !info.IsDir() {
+		result.Path = filepath.Dir(path)
+		result.Entries = append(result.Entries, newFileEntry(path, info))
+		return result, nil, nil
+	}
+
+	entries, err := readDir(path, opts)
+	if err != nil {
+		return rbresult.Listing{}, nil, fmt.Errorf("failed to read directory %s: %w", path, err)
+	}
+
+	warnings := make([]string, 0)
+	var walk func(entries []rbresult.File, depth int)
+	walk = func(entries []rbresult.File, depth int) {
+		for _, entry := range entries {
+			if result.Truncated {
+				return
+			}
+			if opts.pattern == "" || matches(opts.pattern, entry.Name) {
+				if len(result.Entries) == opts.limit {
+					result.Truncated = true
+					return
+				}
+				result.Entries = append(result.Entries, entry)
+			}
+
+			if !opts.recursive || entry.Type != "dir" || (opts.depth > 0 && depth >= opts.depth) {
+				continue
+			}
+			children, err := readDir(entry.Path, opts)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("failed to read directory %s: %v", entry.Path, err))
+				continue
+			}
+			walk(children, depth+1)
+		}
+	}
+	walk(entries, 0)
+	return result, warnings, nil
+}
+
+// readDir describes the entries of a directory in the requested order.
+func readDir(path string, opts lsOptions) ([]rbresult.File, error) {
+	dirEntries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]rbresult.File, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		info, err := dirEntry.Info()
+		if errors.Is(err, os.ErrNotExist) {
+			// Removed since the directory was read
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, newFileEntry(filepath.Join(path, dirEntry.Name()), info))
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if opts.reverse {
+			a, b = b, a
+		}
+		switch opts.sortBy {
+		case "size":
+			if a.Size != b.Size {
+				return a.Size > b.Size
+			}
+		case "time":
+			if !a.ModTime.Equal(b.ModTime) {
+				return a.ModTime.After(b.ModTime)
+			}
+		}
+		return a.Name < b.Name
+	})
+	return entries, nil
+}
+
+func matches(pattern, name string) bool {
+	ok, _ := filepath.Match(pattern, name)
+	return ok
 }
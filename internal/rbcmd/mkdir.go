@@ -0,0 +1,41 @@
+package rbcmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+type MkdirCommand struct{}
+
+func (c *MkdirCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	flags := flag.NewFlagSet("mkdir", flag.ContinueOnError)
+	parents := flags.Bool("p", false, "create parent directories as needed, and do not fail if the directory exists")
+	asJSON := flags.Bool("json", false, "structured JSON result")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+	if len(args) == 0 {
+		return "", "", errors.New("expected at least one path")
+	}
+
+	created := make([]string, 0, len(args))
+	for _, arg := range args {
+		path := resolvePath(ctx, arg)
+		if *parents {
+			err = os.MkdirAll(path, 0755)
+		} else {
+			err = os.Mkdir(path, 0755)
+		}
+		if err != nil {
+			out, _ := changeOutput(*asJSON, "created", created)
+			return out, "", fmt.Errorf("failed to create directory %s: %w", path, err)
+		}
+		created = append(created, path)
+	}
+
+	out, err := changeOutput(*asJSON, "created", created)
+	return out, "", err
+}
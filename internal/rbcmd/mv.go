@@ -0,0 +1,65 @@
+package rbcmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/google/uuid"
+)
+
+type MvCommand struct{}
+
+func (c *MvCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	flags := flag.NewFlagSet("mv", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "structured JSON result")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+	if len(args) < 2 {
+		return "", "", errors.New("expected a source and a destination")
+	}
+
+	sources, dst := args[:len(args)-1], resolvePath(ctx, args[len(args)-1])
+	moved := make([]string, 0, len(sources))
+	for _, arg := range sources {
+		src := resolvePath(ctx, arg)
+		target, err := destination(src, dst, len(sources))
+		if err == nil {
+			err = move(ctx, src, target)
+		}
+		if err != nil {
+			out, _ := changeOutput(*asJSON, "moved", moved)
+			return out, "", fmt.Errorf("failed to move %s: %w", src, err)
+		}
+		moved = append(moved, target)
+	}
+
+	out, err := changeOutput(*asJSON, "moved", moved)
+	return out, "", err
+}
+
+// move renames src, falling back to copying and removing it when the
+// destination is on another filesystem. The copy goes to a temporary name
+// beside dst and is only renamed over it once complete, so a failed copy
+// never touches a file already at dst.
+func move(ctx *Context, src, dst string) error {
+	if err := os.Rename(src, dst); !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	tmp := filepath.Join(filepath.Dir(dst), fmt.Sprintf(".%s.mv-%s", filepath.Base(dst), uuid.New().String()[:8]))
+	if _, err := copyPath(ctx.Ctx, src, tmp, true); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	return os.RemoveAll(src)
+}
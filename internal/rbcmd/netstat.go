@@ -0,0 +1,89 @@
+package rbcmd
+
+import (
+	"flag"
+	"fmt"
+	"redbull/internal/rbresult"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+type NetstatCommand struct{}
+
+func (c *NetstatCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	flags := flag.NewFlagSet("netstat", flag.ContinueOnError)
+	routes := flags.Bool("r", false, "list the routing table instead of sockets")
+	listening := flags.Bool("l", false, "only list listening sockets")
+	proto := flags.String("proto", "", "only list tcp or udp sockets")
+	asJSON := flags.Bool("json", false, "structured JSON result")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+	if len(args) > 0 {
+		return "", "", fmt.Errorf("unexpected argument '%s'", args[0])
+	}
+	if *proto != "" && *proto != "tcp" && *proto != "udp" {
+		return "", "", fmt.Errorf("invalid proto '%s': expected tcp or udp", *proto)
+	}
+
+	if *routes {
+		table, err := listRoutes()
+		if err != nil {
+			return "", "", err
+		}
+		if *asJSON {
+			out, err := toJSON(table)
+			return out, "", err
+		}
+		return formatRoutes(table), "", nil
+	}
+
+	all, err := listSockets()
+	if err != nil {
+		return "", "", err
+	}
+	sockets := make([]rbresult.Socket, 0, len(all))
+	for _, socket := range all {
+		if *proto != "" && !strings.HasPrefix(socket.Proto, *proto) {
+			continue
+		}
+		if *listening && socket.State != "LISTEN" && !(strings.HasPrefix(socket.Proto, "udp") && socket.State == "") {
+			continue
+		}
+		sockets = append(sockets, socket)
+	}
+
+	if *asJSON {
+		out, err := toJSON(sockets)
+		return out, "", err
+	}
+	return formatSockets(sockets), "", nil
+}
+
+func formatSockets(sockets []rbresult.Socket) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROTO\tLOCAL\tREMOTE\tSTATE\tUSER\tPROCESS")
+	for _, socket := range sockets {
+		process := "-"
+		if socket.PID > 0 {
+			process = strconv.Itoa(socket.PID) + "/" + socket.Process
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", socket.Proto, socket.Local, socket.Remote, dashIfEmpty(socket.State), dashIfEmpty(socket.User), process)
+	}
+	w.Flush()
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func formatRoutes(routes []rbresult.Route) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DESTINATION\tGATEWAY\tINTERFACE\tMETRIC")
+	for _, route := range routes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", route.Destination, dashIfEmpty(route.Gateway), route.Interface, route.Metric)
+	}
+	w.Flush()
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,259 @@
+package rbcmd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"redbull/internal/rbresult"
+	"strconv"
+	"strings"
+)
+
+var tcpStates = map[string]string{
+	"01": "ESTABLISHED",
+	"02": "SYN_SENT",
+	"03": "SYN_RECV",
+	"04": "FIN_WAIT1",
+	"05": "FIN_WAIT2",
+	"06": "TIME_WAIT",
+	"07": "CLOSE",
+	"08": "CLOSE_WAIT",
+	"09": "LAST_ACK",
+	"0A": "LISTEN",
+	"0B": "CLOSING",
+}
+
+// listSockets reads the TCP and UDP socket tables from /proc/net. Tables a
+// kernel lacks, such as tcp6 with IPv6 disabled, are skipped.
+func listSockets() ([]rbresult.Socket, error) {
+	owners := socketOwners()
+	sockets := make([]rbresult.Socket, 0)
+	read := 0
+	for _, proto := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		table, err := readSocketTable(proto, owners)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sockets = append(sockets, table...)
+		read++
+	}
+	if read == 0 {
+		return nil, errors.New("failed to read sockets: no tables in /proc/net")
+	}
+	return sockets, nil
+}
+
+func readSocketTable(proto string, owners map[string]int) ([]rbresult.Socket, error) {
+	file, err := os.Open(filepath.Join("/proc/net", proto))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sockets := make([]rbresult.Socket, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		local, err := parseSocketAddr(fields[1])
+		if err != nil {
+			continue
+		}
+		remote, err := parseSocketAddr(fields[2])
+		if err != nil {
+			continue
+		}
+
+		socket := rbresult.Socket{
+			Proto:  proto,
+			Local:  local,
+			Remote: remote,
+			State:  tcpStates[fields[3]],
+			User:   lookupName(&userNames, fields[7], lookupUser),
+		}
+		if strings.HasPrefix(proto, "udp") {
+			// UDP has no states, only whether the socket is connected
+			socket.State = ""
+			if fields[3] == "01" {
+				socket.State = "ESTABLISHED"
+			}
+		}
+		if pid, ok := owners[fields[9]]; ok {
+			socket.PID = pid
+			socket.Process = processName(pid)
+		}
+		sockets = append(sockets, socket)
+	}
+	return sockets, scanner.Err()
+}
+
+// parseSocketAddr decodes an address such as 0100007F:1F90. The address is
+// printed as 32-bit words in host byte order, the port in plain hex.
+func parseSocketAddr(s string) (string, error) {
+	addr, port, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", fmt.Errorf("malformed address '%s'", s)
+	}
+	ip, err := parseHostOrderIP(addr)
+	if err != nil {
+		return "", err
+	}
+	p, err := strconv.ParseUint(port, 16, 16)
+	if err != nil {
+		return "", fmt.Errorf("malformed port '%s'", port)
+	}
+	return net.JoinHostPort(ip.String(), strconv.FormatUint(p, 10)), nil
+}
+
+func parseHostOrderIP(s string) (net.IP, error) {
+	if len(s) != 8 && len(s) != 32 {
+		return nil, fmt.Errorf("malformed address '%s'", s)
+	}
+	ip := make(net.IP, len(s)/2)
+	for i := 0; i < len(s); i += 8 {
+		word, err := strconv.ParseUint(s[i:i+8], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("malformed address '%s'", s)
+		}
+		binary.NativeEndian.PutUint32(ip[i/2:], uint32(word))
+	}
+	return ip, nil
+}
+
+// socketOwners maps socket inodes to the pid holding them open, from the fd
+// links of every process the beacon is allowed to read.
+func socketOwners() map[string]int {
+	owners := make(map[string]int)
+	dirs, err := os.ReadDir("/proc")
+	if err != nil {
+		return owners
+	}
+	for _, dir := range dirs {
+		pid, err := strconv.Atoi(dir.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join("/proc", dir.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil {
+				continue
+			}
+			if inode, ok := strings.CutPrefix(link, "socket:["); ok {
+				owners[strings.TrimSuffix(inode, "]")] = pid
+			}
+		}
+	}
+	return owners
+}
+
+func processName(pid int) string {
+	comm, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "comm"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}
+
+// listRoutes reads the IPv4 and IPv6 routing tables.
+func listRoutes() ([]rbresult.Route, error) {
+	routes, err := readRoutes()
+	if err != nil {
+		return nil, err
+	}
+	if routes6, err := readRoutes6(); err == nil {
+		routes = append(routes, routes6...)
+	}
+	return routes, nil
+}
+
+func readRoutes() ([]rbresult.Route, error) {
+	file, err := os.Open("/proc/net/route")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routes: %w", err)
+	}
+	defer file.Close()
+
+	routes := make([]rbresult.Route, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		// Iface Destination Gateway Flags RefCnt Use Metric Mask MTU Window IRTT
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+		dest, err1 := parseHostOrderIP(fields[1])
+		gateway, err2 := parseHostOrderIP(fields[2])
+		mask, err3 := parseHostOrderIP(fields[7])
+		if err := errors.Join(err1, err2, err3); err != nil {
+			continue
+		}
+		ones, _ := net.IPMask(mask).Size()
+		metric, _ := strconv.Atoi(fields[6])
+
+		route := rbresult.Route{
+			Destination: fmt.Sprintf("%s/%d", dest, ones),
+			Interface:   fields[0],
+			Metric:      metric,
+		}
+		if !gateway.IsUnspecified() {
+			route.Gateway = gateway.String()
+		}
+		routes = append(routes, route)
+	}
+	return routes, scanner.Err()
+}
+
+func readRoutes6() ([]rbresult.Route, error) {
+	file, err := os.Open("/proc/net/ipv6_route")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	routes := make([]rbresult.Route, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// dest dest_prefix src src_prefix next_hop metric refcnt use flags iface,
+		// with addresses in network byte order
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		dest, err1 := hex.DecodeString(fields[0])
+		gateway, err2 := hex.DecodeString(fields[4])
+		prefix, err3 := strconv.ParseUint(fields[1], 16, 8)
+		metric, err4 := strconv.ParseUint(fields[5], 16, 32)
+		if err := errors.Join(err1, err2, err3, err4); err != nil {
+			continue
+		}
+
+		route := rbresult.Route{
+			Destination: fmt.Sprintf("%s/%d", net.IP(dest), prefix),
+			Interface:   fields[9],
+			Metric:      int(metric),
+		}
+		if !net.IP(gateway).IsUnspecified() {
+			route.Gateway = net.IP(gateway).String()
+		}
+		routes = append(routes, route)
+	}
+	return routes, scanner.Err()
+}
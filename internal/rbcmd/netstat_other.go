@@ -0,0 +1,17 @@
+//go:build !linux
+
+package rbcmd
+
+import (
+	"fmt"
+	"redbull/internal/rbresult"
+	"runtime"
+)
+
+func listSockets() ([]rbresult.Socket, error) {
+	return nil, fmt.Errorf("socket listing is not supported on %s", runtime.GOOS)
+}
+
+func listRoutes() ([]rbresult.Route, error) {
+	return nil, fmt.Errorf("route listing is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,58 @@
+//go:build unix
+
+package rbcmd
+
+import (
+	"io/fs"
+	"os/user"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+var (
+	userNames  sync.Map
+	groupNames sync.Map
+)
+
+// fileOwner returns the names of the file's owning user and group, falling
+// back to the numeric IDs when they cannot be looked up.
+func fileOwner(info fs.FileInfo) (string, string) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", ""
+	}
+	uid := strconv.FormatUint(uint64(stat.Uid), 10)
+	gid := strconv.FormatUint(uint64(stat.Gid), 10)
+	return lookupName(&userNames, uid, lookupUser), lookupName(&groupNames, gid, lookupGroup)
+}
+
+func lookupUser(uid string) (string, error) {
+	u, err := user.LookupId(uid)
+	if err != nil {
+		return "", err
+	}
+	return u.Username, nil
+}
+
+func lookupGroup(gid string) (string, error) {
+	g, err := user.LookupGroupId(gid)
+	if err != nil {
+		return "", err
+	}
+	return g.Name, nil
+}
+
+// lookupName caches lookups, since listing a large directory would otherwise
+// look up the same few IDs over and over.
+func lookupName(cache *sync.Map, id string, lookup func(string) (string, error)) string {
+	if name, ok := cache.Load(id); ok {
+		return name.(string)
+	}
+	name, err := lookup(id)
+	if err != nil {
+		name = id
+	}
+	cache.Store(id, name)
+	return name
+}
@@ -0,0 +1,125 @@
+package rbcmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"redbull/internal/rbresult"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is USER_HZ, the unit of process start times in /proc. It is 100
+// on every Linux architecture, and reading it properly would need cgo.
+const clockTicks = 100
+
+// listProcesses reads every process from /proc. Processes that exit while
+// they are being read are left out.
+func listProcesses() ([]rbresult.Process, error) {
+	dirs, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read /proc: %w", err)
+	}
+	boot, err := bootTime()
+	if err != nil {
+		return nil, err
+	}
+
+	processes := make([]rbresult.Process, 0, len(dirs))
+	for _, dir := range dirs {
+		pid, err := strconv.Atoi(dir.Name())
+		if err != nil || !dir.IsDir() {
+			continue
+		}
+		process, err := readProcess(pid, boot)
+		if err != nil {
+			continue
+		}
+		processes = append(processes, process)
+	}
+	return processes, nil
+}
+
+func readProcess(pid int, boot time.Time) (rbresult.Process, error) {
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return rbresult.Process{}, err
+	}
+
+	// The name is in parentheses and may itself contain spaces and
+	// parentheses, so the fields after it start at the last ')'
+	start, end := bytes.IndexByte(stat, '('), bytes.LastIndexByte(stat, ')')
+	if start < 0 || end < start {
+		return rbresult.Process{}, errors.New("malformed stat")
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 20 {
+		return rbresult.Process{}, errors.New("malformed stat")
+	}
+	ppid, _ := strconv.Atoi(fields[1])
+	started, _ := strconv.ParseInt(fields[19], 10, 64)
+
+	process := rbresult.Process{
+		PID:       pid,
+		PPID:      ppid,
+		Name:      string(stat[start+1 : end]),
+		State:     fields[0],
+		StartTime: boot.Add(time.Duration(started) * time.Second / clockTicks),
+	}
+	if uid, ok := processUID(dir); ok {
+		process.User = lookupName(&userNames, uid, lookupUser)
+	}
+
+	cmdline, _ := os.ReadFile(filepath.Join(dir, "cmdline"))
+	process.Command = strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))
+	if process.Command == "" {
+		// Kernel threads have no command line
+		process.Command = "[" + process.Name + "]"
+	}
+	return process, nil
+}
+
+// processUID returns the real user ID from the process status.
+func processUID(dir string) (string, bool) {
+	file, err := os.Open(filepath.Join(dir, "status"))
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rest, ok := strings.CutPrefix(scanner.Text(), "Uid:"); ok {
+			fields := strings.Fields(rest)
+			if len(fields) > 0 {
+				return fields[0], true
+			}
+		}
+	}
+	return "", false
+}
+
+func bootTime() (time.Time, error) {
+	file, err := os.Open("/proc/stat")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read boot time: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rest, ok := strings.CutPrefix(scanner.Text(), "btime "); ok {
+			secs, err := strconv.ParseInt(strings.TrimSpace(rest), 10, 64)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("failed to read boot time: %w", err)
+			}
+			return time.Unix(secs, 0), nil
+		}
+	}
+	return time.Time{}, errors.New("failed to read boot time: no btime in /proc/stat")
+}
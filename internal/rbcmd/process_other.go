@@ -0,0 +1,13 @@
+//go:build !linux
+
+package rbcmd
+
+import (
+	"fmt"
+	"redbull/internal/rbresult"
+	"runtime"
+)
+
+func listProcesses() ([]rbresult.Process, error) {
+	return nil, fmt.Errorf("process listing is not supported on %s", runtime.GOOS)
+}
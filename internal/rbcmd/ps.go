@@ -0,0 +1,85 @@
+package rbcmd
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"redbull/internal/rbresult"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+type PsCommand struct{}
+
+func (c *PsCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	flags := flag.NewFlagSet("ps", flag.ContinueOnError)
+	user := flags.String("user", "", "only list processes run by this user")
+	pattern := flags.String("name", "", "only list processes whose name matches the glob")
+	sortBy := flags.String("sort", "pid", "sort by pid, start or name")
+	asJSON := flags.Bool("json", false, "structured JSON result")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+	if len(args) > 0 {
+		return "", "", fmt.Errorf("unexpected argument '%s'", args[0])
+	}
+	if _, err := filepath.Match(*pattern, ""); err != nil {
+		return "", "", fmt.Errorf("invalid pattern '%s': %w", *pattern, err)
+	}
+	if *sortBy != "pid" && *sortBy != "start" && *sortBy != "name" {
+		return "", "", fmt.Errorf("invalid sort '%s': expected pid, start or name", *sortBy)
+	}
+
+	all, err := listProcesses()
+	if err != nil {
+		return "", "", err
+	}
+	processes := make([]rbresult.Process, 0, len(all))
+	for _, process := range all {
+		if *user != "" && process.User != *user {
+			continue
+		}
+		if *pattern != "" && !matches(*pattern, process.Name) {
+			continue
+		}
+		processes = append(processes, process)
+	}
+	sortProcesses(processes, *sortBy)
+
+	if *asJSON {
+		out, err := toJSON(processes)
+		return out, "", err
+	}
+	return formatProcesses(processes), "", nil
+}
+
+func sortProcesses(processes []rbresult.Process, sortBy string) {
+	sort.SliceStable(processes, func(i, j int) bool {
+		a, b := processes[i], processes[j]
+		switch sortBy {
+		case "start":
+			if !a.StartTime.Equal(b.StartTime) {
+				return a.StartTime.Before(b.StartTime)
+			}
+		case "name":
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+		}
+		return a.PID < b.PID
+	})
+}
+
+func formatProcesses(processes []rbresult.Process) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PID\tPPID\tUSER\tSTATE\tSTARTED\tCOMMAND")
+	for _, process := range processes {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%s\n", process.PID, process.PPID, dashIfEmpty(process.User), dashIfEmpty(process.State),
+			process.StartTime.Format("2006-01-02 15:04:05"), process.Command)
+	}
+	w.Flush()
+	return strings.TrimRight(b.String(), "\n")
+}
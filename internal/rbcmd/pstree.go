@@ -0,0 +1,108 @@
+package rbcmd
+
+import (
+	"flag"
+	"fmt"
+	"redbull/internal/rbresult"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type PstreeCommand struct{}
+
+// ProcessNode is a process and its descendants in the structured result of
+// pstree -json.
+type ProcessNode struct {
+	rbresult.Process
+	Children []ProcessNode `json:"children,omitempty"`
+}
+
+func (c *PstreeCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	flags := flag.NewFlagSet("pstree", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "structured JSON result")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+	if len(args) > 1 {
+		return "", "", fmt.Errorf("expected at most one pid, got %d", len(args))
+	}
+
+	processes, err := listProcesses()
+	if err != nil {
+		return "", "", err
+	}
+	roots, err := processTree(processes, args)
+	if err != nil {
+		return "", "", err
+	}
+
+	if *asJSON {
+		out, err := toJSON(roots)
+		return out, "", err
+	}
+	var b strings.Builder
+	for _, root := range roots {
+		writeProcessTree(&b, root, "", "")
+	}
+	return strings.TrimRight(b.String(), "\n"), "", nil
+}
+
+// processTree links processes to their parents, returning the tree under the
+// pid in args, or every process whose parent is not running.
+func processTree(processes []rbresult.Process, args []string) ([]ProcessNode, error) {
+	sortProcesses(processes, "pid")
+	byPID := make(map[int]rbresult.Process, len(processes))
+	children := make(map[int][]rbresult.Process)
+	for _, process := range processes {
+		byPID[process.PID] = process
+		children[process.PPID] = append(children[process.PPID], process)
+	}
+
+	var build func(process rbresult.Process) ProcessNode
+	build = func(process rbresult.Process) ProcessNode {
+		node := ProcessNode{Process: process}
+		for _, child := range children[process.PID] {
+			// A process reparented to itself would otherwise recurse forever
+			if child.PID != process.PID {
+				node.Children = append(node.Children, build(child))
+			}
+		}
+		return node
+	}
+
+	if len(args) == 1 {
+		pid, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pid '%s'", args[0])
+		}
+		process, ok := byPID[pid]
+		if !ok {
+			return nil, fmt.Errorf("no process %d", pid)
+		}
+		return []ProcessNode{build(process)}, nil
+	}
+
+	roots := make([]ProcessNode, 0)
+	for _, process := range processes {
+		if _, ok := byPID[process.PPID]; !ok || process.PPID == process.PID {
+			roots = append(roots, build(process))
+		}
+	}
+	sort.SliceStable(roots, func(i, j int) bool {
+		return roots[i].PID < roots[j].PID
+	})
+	return roots, nil
+}
+
+func writeProcessTree(b *strings.Builder, node ProcessNode, prefix, childPrefix string) {
+	fmt.Fprintf(b, "%s%d %s\n", prefix, node.PID, node.Name)
+	for i, child := range node.Children {
+		if i == len(node.Children)-1 {
+			writeProcessTree(b, child, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			writeProcessTree(b, child, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
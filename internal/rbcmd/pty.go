@@ -0,0 +1,82 @@
+package rbcmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"redbull/internal/rbpty"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PtyCommand struct{}
+
+func (c *PtyCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	if ctx.Ptys == nil {
+		return "", "", errors.New("ptys are not available")
+	}
+
+	flags := flag.NewFlagSet("pty", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "structured JSON result")
+	id := flags.String("id", "", "ID for the new terminal")
+	cols := flags.Int("cols", 80, "terminal width")
+	rows := flags.Int("rows", 24, "terminal height")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch {
+	case len(args) == 0 || args[0] == "list" && len(args) == 1:
+		infos := ctx.Ptys.List()
+		if *asJSON {
+			out, err := toJSON(infos)
+			return out, "", err
+		}
+		return formatPtys(infos), "", nil
+	case args[0] == "open" && len(args) <= 2:
+		if *cols <= 0 || *rows <= 0 {
+			return "", "", errors.New("cols and rows must be positive")
+		}
+		if *id == "" {
+			*id = uuid.New().String()
+		}
+		shell := rbpty.DefaultShell()
+		if len(args) == 2 {
+			shell = args[1]
+		}
+		info, err := ctx.Ptys.Open(*id, shell, *cols, *rows)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to open pty: %w", err)
+		}
+		if *asJSON {
+			out, err := toJSON(info)
+			return out, "", err
+		}
+		return fmt.Sprintf("opened pty %s: %s (pid %d, %dx%d)", info.ID, info.Shell, info.PID, info.Cols, info.Rows), "", nil
+	case args[0] == "close" && len(args) == 2:
+		if err := ctx.Ptys.Close(args[1]); err != nil {
+			return "", "", err
+		}
+		return "closed pty " + args[1], "", nil
+	default:
+		return "", "", errors.New("expected list, open [shell] or close <id>")
+	}
+}
+
+func formatPtys(infos []rbpty.Info) string {
+	if len(infos) == 0 {
+		return "no open ptys"
+	}
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPID\tSHELL\tSIZE\tSTARTED")
+	for _, info := range infos {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%dx%d\t%s\n", info.ID, info.PID, info.Shell, info.Cols, info.Rows, info.StartedAt.Format(time.RFC3339))
+	}
+	w.Flush()
+	return strings.TrimRight(b.String(), "\n")
+}
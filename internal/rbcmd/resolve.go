@@ -0,0 +1,75 @@
+package rbcmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+type ResolveCommand struct{}
+
+// ResolveResult is the structured result of resolve -json. Names are set for
+// reverse lookups of an IP, Addresses for everything else.
+type ResolveResult struct {
+	Name      string   `json:"name"`
+	CNAME     string   `json:"cname,omitempty"`
+	Addresses []string `json:"addresses,omitempty"`
+	Names     []string `json:"names,omitempty"`
+}
+
+func (c *ResolveCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	flags := flag.NewFlagSet("resolve", flag.ContinueOnError)
+	timeout := flags.Duration("timeout", 5*time.Second, "how long to wait for an answer")
+	asJSON := flags.Bool("json", false, "structured JSON result")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+	if len(args) != 1 {
+		return "", "", errors.New("expected a name to resolve")
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx.Ctx, *timeout)
+	defer cancel()
+
+	name := args[0]
+	result := ResolveResult{Name: name}
+	if net.ParseIP(name) != nil {
+		result.Names, err = net.DefaultResolver.LookupAddr(lookupCtx, name)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to resolve %s: %w", name, err)
+		}
+	} else {
+		addrs, err := net.DefaultResolver.LookupIPAddr(lookupCtx, name)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to resolve %s: %w", name, err)
+		}
+		for _, addr := range addrs {
+			result.Addresses = append(result.Addresses, addr.String())
+		}
+		// Not every resolver answers CNAME queries, so this is best effort
+		if cname, err := net.DefaultResolver.LookupCNAME(lookupCtx, name); err == nil && strings.TrimSuffix(cname, ".") != strings.TrimSuffix(name, ".") {
+			result.CNAME = cname
+		}
+	}
+
+	if *asJSON {
+		out, err := toJSON(result)
+		return out, "", err
+	}
+	lines := make([]string, 0)
+	if result.CNAME != "" {
+		lines = append(lines, fmt.Sprintf("%s is an alias for %s", name, result.CNAME))
+	}
+	for _, addr := range result.Addresses {
+		lines = append(lines, fmt.Sprintf("%s has address %s", name, addr))
+	}
+	for _, n := range result.Names {
+		lines = append(lines, fmt.Sprintf("%s has name %s", name, n))
+	}
+	return strings.Join(lines, "\n"), "", nil
+}
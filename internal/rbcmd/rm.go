@@ -0,0 +1,58 @@
+package rbcmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+type RmCommand struct{}
+
+func (c *RmCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	flags := flag.NewFlagSet("rm", flag.ContinueOnError)
+	recursive := flags.Bool("r", false, "remove directories and their contents")
+	force := flags.Bool("f", false, "ignore paths that do not exist")
+	asJSON := flags.Bool("json", false, "structured JSON result")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+	if len(args) == 0 {
+		return "", "", errors.New("expected at least one path")
+	}
+
+	removed := make([]string, 0, len(args))
+	for _, arg := range args {
+		path := resolvePath(ctx, arg)
+		if err := remove(path, *recursive); err != nil {
+			if *force && errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			out, _ := changeOutput(*asJSON, "removed", removed)
+			return out, "", fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		removed = append(removed, path)
+	}
+
+	out, err := changeOutput(*asJSON, "removed", removed)
+	return out, "", err
+}
+
+func remove(path string, recursive bool) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return os.Remove(path)
+	}
+	if !recursive {
+		return errors.New("is a directory (use -r)")
+	}
+	if filepath.Dir(path) == path {
+		return errors.New("refusing to remove the root directory")
+	}
+	return os.RemoveAll(path)
+}
@@ -8,18 +8,18 @@ import (
 	"time"
 )
 
-type ShellCommand struct{}
+// shellTimeout bounds a shell or shellsession command.
+const shellTimeout = 100 * time.Second
 
-func (c *ShellCommand) Help() string {
-	return "Execute a shell command: shell <command>"
-}
+type ShellCommand struct{}
 
 func (c *ShellCommand) Execute(ctx *Context, cmd string) (string, string, error) {
-	timeout := 100 * time.Second
-	ctxTimeout, cancel := context.WithTimeout(context.Background(), timeout)
+	ctxTimeout, cancel := context.WithTimeout(ctx.Ctx, shellTimeout)
 	defer cancel()
 
 	execCmd := exec.CommandContext(ctxTimeout, "bash", "-c", cmd)
+	// Don't wait on pipes held open by children that outlive a killed bash
+	execCmd.WaitDelay = time.Second
 
 	var outBuf, errBuf bytes.Buffer
 	execCmd.Stdout = &outBuf
@@ -27,7 +27,7 @@ func (c *ShellCommand) Execute(ctx *Context, cmd string) (string, string, error)
 
 	err := execCmd.Run()
 	if ctxTimeout.Err() == context.DeadlineExceeded {
-		return outBuf.String(), errBuf.String(), fmt.Errorf("connection timed out after %v", timeout)
+		return outBuf.String(), errBuf.String(), fmt.Errorf("connection timed out after %v", shellTimeout)
 	}
 
 	return outBuf.String(), errBuf.String(), err
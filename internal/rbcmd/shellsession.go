@@ -0,0 +1,76 @@
+package rbcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"redbull/internal/rbshell"
+	"strings"
+	"time"
+)
+
+type ShellsessionCommand struct{}
+
+func (c *ShellsessionCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	if ctx.ShellSession == nil {
+		return "", "", errors.New("shell sessions are not available")
+	}
+
+	fields := strings.Fields(cmd)
+	if len(fields) == 0 {
+		return "", "", errors.New("expected a command, -status, -reset or -close")
+	}
+	switch fields[0] {
+	case "-status":
+		return shellSessionStatus(ctx, fields[1:])
+	case "-reset":
+		info, err := ctx.ShellSession.Reset(ctx.CWD.Get())
+		if err != nil {
+			return "", "", fmt.Errorf("failed to reset shell session: %w", err)
+		}
+		return fmt.Sprintf("started a new shell session: %s (pid %d) in %s", info.Path, info.PID, info.Dir), "", nil
+	case "-close":
+		if !ctx.ShellSession.Close() {
+			return "no shell session is running", "", nil
+		}
+		return "closed the shell session", "", nil
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(ctx.Ctx, shellTimeout)
+	defer cancel()
+
+	result, err := ctx.ShellSession.Run(ctxTimeout, ctx.CWD.Get(), cmd)
+	switch {
+	case errors.Is(err, rbshell.ErrShellExited):
+		return result.Stdout, result.Stderr, fmt.Errorf("the shell session exited with status %d, the next command starts a new one", result.ExitCode)
+	case err != nil && ctx.Ctx.Err() != nil:
+		return result.Stdout, result.Stderr, errors.New("cancelled, the shell session was closed")
+	case err != nil && ctxTimeout.Err() == context.DeadlineExceeded:
+		return result.Stdout, result.Stderr, fmt.Errorf("timed out after %v, the shell session was closed", shellTimeout)
+	case err != nil:
+		return result.Stdout, result.Stderr, err
+	case result.ExitCode != 0:
+		return result.Stdout, result.Stderr, fmt.Errorf("exit status %d", result.ExitCode)
+	}
+	return result.Stdout, result.Stderr, nil
+}
+
+func shellSessionStatus(ctx *Context, args []string) (string, string, error) {
+	asJSON := len(args) == 1 && args[0] == "-json"
+	if len(args) > 0 && !asJSON {
+		return "", "", errors.New("expected -status [-json]")
+	}
+
+	info, ok := ctx.ShellSession.Info()
+	if asJSON {
+		if !ok {
+			return "null", "", nil
+		}
+		out, err := toJSON(info)
+		return out, "", err
+	}
+	if !ok {
+		return "no shell session is running, the next command starts one", "", nil
+	}
+	return fmt.Sprintf("%s (pid %d) started in %s at %s, %d commands run", info.Path, info.PID, info.Dir, info.StartedAt.Format(time.RFC3339), info.Commands), "", nil
+}
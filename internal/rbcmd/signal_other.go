@@ -0,0 +1,22 @@
+//go:build !unix
+
+package rbcmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const defaultSignal = "KILL"
+
+// parseSignal accepts only KILL (or 9), the one signal os.Process can send
+// outside Unix.
+func parseSignal(s string) (os.Signal, string, error) {
+	switch strings.TrimPrefix(strings.ToUpper(s), "SIG") {
+	case "KILL", "9":
+		return os.Kill, "KILL", nil
+	default:
+		return nil, "", fmt.Errorf("unsupported signal '%s': only KILL is supported on this platform", s)
+	}
+}
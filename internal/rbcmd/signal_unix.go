@@ -0,0 +1,48 @@
+//go:build unix
+
+package rbcmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const defaultSignal = "TERM"
+
+var signals = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+	"TERM": syscall.SIGTERM,
+	"CONT": syscall.SIGCONT,
+	"STOP": syscall.SIGSTOP,
+}
+
+// parseSignal accepts a signal name, with or without the SIG prefix, or a
+// number, and returns the signal along with its canonical name.
+func parseSignal(s string) (os.Signal, string, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		for name, sig := range signals {
+			if int(sig) == n {
+				return sig, name, nil
+			}
+		}
+		if n <= 0 || n > 64 {
+			return nil, "", fmt.Errorf("invalid signal '%s'", s)
+		}
+		return syscall.Signal(n), s, nil
+	}
+
+	name := strings.TrimPrefix(strings.ToUpper(s), "SIG")
+	sig, ok := signals[name]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown signal '%s'", s)
+	}
+	return sig, name, nil
+}
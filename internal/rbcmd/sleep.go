@@ -8,10 +8,6 @@ import (
 
 type SleepCommand struct{}
 
-func (c *SleepCommand) Help() string {
-	return "Set the sleep time between check-ins (in seconds): sleep <seconds>"
-}
-
 func (c *SleepCommand) Execute(ctx *Context, cmd string) (string, string, error) {
 	// Check if the cmd can be turned into an int
 	sleepTimeInt, err := strconv.Atoi(cmd)
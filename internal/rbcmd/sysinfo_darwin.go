@@ -0,0 +1,36 @@
+package rbcmd
+
+import (
+	"encoding/binary"
+	"syscall"
+	"time"
+)
+
+func collectSystemInfo(info *HostInfo) {
+	if version, err := syscall.Sysctl("kern.osproductversion"); err == nil {
+		info.Release = "macOS " + version
+	}
+	if model, err := syscall.Sysctl("machdep.cpu.brand_string"); err == nil {
+		info.CPUModel = model
+	}
+	if boottime, ok := sysctlBytes("kern.boottime", 16); ok {
+		// struct timeval, whose first field is the seconds
+		info.BootTime = time.Unix(int64(binary.LittleEndian.Uint64(boottime)), 0)
+	}
+	if memsize, ok := sysctlBytes("hw.memsize", 8); ok {
+		info.MemoryTotal = int64(binary.LittleEndian.Uint64(memsize))
+	}
+}
+
+// sysctlBytes reads a binary sysctl value. syscall.Sysctl treats every value
+// as a string and drops a trailing zero byte, so the value is padded back out
+// to size.
+func sysctlBytes(name string, size int) ([]byte, bool) {
+	value, err := syscall.Sysctl(name)
+	if err != nil || len(value) > size {
+		return nil, false
+	}
+	b := make([]byte, size)
+	copy(b, value)
+	return b, true
+}
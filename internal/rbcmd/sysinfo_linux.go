@@ -0,0 +1,99 @@
+package rbcmd
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func collectSystemInfo(info *HostInfo) {
+	info.Release = osRelease()
+	if boot, err := bootTime(); err == nil {
+		info.BootTime = boot
+	}
+	info.CPUModel = cpuModel()
+
+	mem := meminfo()
+	info.MemoryTotal, info.MemoryAvailable = mem["MemTotal"], mem["MemAvailable"]
+
+	if loadavg, err := os.ReadFile("/proc/loadavg"); err == nil {
+		fields := strings.Fields(string(loadavg))
+		for _, field := range fields[:min(3, len(fields))] {
+			load, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				break
+			}
+			info.LoadAverage = append(info.LoadAverage, load)
+		}
+	}
+}
+
+// osRelease returns the distribution's name and version from os-release.
+func osRelease() string {
+	for _, path := range []string{"/etc/os-release", "/usr/lib/os-release"} {
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if value, ok := strings.CutPrefix(scanner.Text(), "PRETTY_NAME="); ok {
+				return strings.Trim(value, `"'`)
+			}
+		}
+		return ""
+	}
+	return ""
+}
+
+func cpuModel() string {
+	file, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		// x86 calls it model name; several ARM kernels only give Hardware
+		if ok && (strings.TrimSpace(key) == "model name" || strings.TrimSpace(key) == "Hardware") {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// meminfo reads /proc/meminfo in bytes, keyed by field name.
+func meminfo() map[string]int64 {
+	mem := make(map[string]int64)
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return mem
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		n, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			n *= 1024
+		}
+		mem[key] = n
+	}
+	return mem
+}
@@ -0,0 +1,5 @@
+//go:build !linux && !darwin
+
+package rbcmd
+
+func collectSystemInfo(info *HostInfo) {}
@@ -0,0 +1,64 @@
+package rbcmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"time"
+)
+
+type TcpcheckCommand struct{}
+
+// TcpcheckResult is the structured result of tcpcheck -json.
+type TcpcheckResult struct {
+	Target    string  `json:"target"`
+	Address   string  `json:"address,omitempty"`
+	Open      bool    `json:"open"`
+	LatencyMs float64 `json:"latencyMs,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+func (c *TcpcheckCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	flags := flag.NewFlagSet("tcpcheck", flag.ContinueOnError)
+	timeout := flags.Duration("timeout", 5*time.Second, "how long to wait for the connection")
+	asJSON := flags.Bool("json", false, "structured JSON result")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+	if len(args) != 1 {
+		return "", "", errors.New("expected a host:port")
+	}
+	target := args[0]
+	if _, _, err := net.SplitHostPort(target); err != nil {
+		return "", "", fmt.Errorf("invalid target '%s': %w", target, err)
+	}
+
+	result := TcpcheckResult{Target: target}
+	dialer := net.Dialer{Timeout: *timeout}
+	start := time.Now()
+	conn, dialErr := dialer.DialContext(ctx.Ctx, "tcp", target)
+	if dialErr == nil {
+		result.Open = true
+		result.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+		result.Address = conn.RemoteAddr().String()
+		conn.Close()
+	} else {
+		result.Error = dialErr.Error()
+	}
+
+	// A closed port is reported as an error too, so the task counts as failed
+	var stdout string
+	if *asJSON {
+		if stdout, err = toJSON(result); err != nil {
+			return "", "", err
+		}
+	} else if result.Open {
+		stdout = fmt.Sprintf("%s is open (%s, %.1fms)", target, result.Address, result.LatencyMs)
+	}
+	if dialErr != nil {
+		return stdout, "", fmt.Errorf("%s is not reachable: %w", target, dialErr)
+	}
+	return stdout, "", nil
+}
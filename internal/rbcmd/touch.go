@@ -0,0 +1,60 @@
+package rbcmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+type TouchCommand struct{}
+
+func (c *TouchCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	flags := flag.NewFlagSet("touch", flag.ContinueOnError)
+	noCreate := flags.Bool("c", false, "do not create files that do not exist")
+	at := flags.String("time", "", "time to set instead of now")
+	asJSON := flags.Bool("json", false, "structured JSON result")
+	args, err := parseArgs(flags, cmd)
+	if err != nil {
+		return "", "", err
+	}
+	if len(args) == 0 {
+		return "", "", errors.New("expected at least one path")
+	}
+
+	when := time.Now()
+	if *at != "" {
+		when, err = time.Parse(time.RFC3339, *at)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid time '%s': expected RFC3339", *at)
+		}
+	}
+
+	touched := make([]string, 0, len(args))
+	for _, arg := range args {
+		path := resolvePath(ctx, arg)
+		if err := touch(path, when, !*noCreate); err != nil {
+			if *noCreate && errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			out, _ := changeOutput(*asJSON, "touched", touched)
+			return out, "", fmt.Errorf("failed to touch %s: %w", path, err)
+		}
+		touched = append(touched, path)
+	}
+
+	out, err := changeOutput(*asJSON, "touched", touched)
+	return out, "", err
+}
+
+func touch(path string, when time.Time, create bool) error {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) && create {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		file.Close()
+	}
+	return os.Chtimes(path, when, when)
+}
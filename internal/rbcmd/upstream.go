@@ -0,0 +1,104 @@
+package rbcmd
+
+import (
+	"fmt"
+	"redbull/internal/rbtransport"
+	"strconv"
+	"strings"
+)
+
+type UpstreamCommand struct{}
+
+func (c *UpstreamCommand) Execute(ctx *Context, cmd string) (string, string, error) {
+	args := strings.Fields(cmd)
+	if len(args) == 0 || args[0] == "list" {
+		return formatUpstreams(ctx.Upstreams.Endpoints()), "", nil
+	}
+
+	switch args[0] {
+	case "add":
+		endpoint, err := parseEndpoint(args[1:])
+		if err != nil {
+			return "", "", err
+		}
+		if err := ctx.Upstreams.Add(endpoint); err != nil {
+			return "", "", err
+		}
+		return fmt.Sprintf("added upstream %s", endpoint.URL), "", nil
+	case "set":
+		if len(args) < 3 {
+			return "", "", fmt.Errorf("invalid command: %s", cmd)
+		}
+		index, err := strconv.Atoi(args[1])
+		if err != nil {
+			return "", "", fmt.Errorf("invalid upstream index '%s': %w", args[1], err)
+		}
+		endpoint, err := parseEndpoint(args[2:])
+		if err != nil {
+			return "", "", err
+		}
+		if err := ctx.Upstreams.Set(index, endpoint); err != nil {
+			return "", "", err
+		}
+		return fmt.Sprintf("set upstream %d to %s", index, endpoint.URL), "", nil
+	case "use", "remove":
+		if len(args) != 2 {
+			return "", "", fmt.Errorf("invalid command: %s", cmd)
+		}
+		index, err := strconv.Atoi(args[1])
+		if err != nil {
+			return "", "", fmt.Errorf("invalid upstream index '%s': %w", args[1], err)
+		}
+		if args[0] == "use" {
+			err = ctx.Upstreams.Use(index)
+		} else {
+			err = ctx.Upstreams.Remove(index)
+		}
+		if err != nil {
+			return "", "", err
+		}
+		return formatUpstreams(ctx.Upstreams.Endpoints()), "", nil
+	default:
+		return "", "", fmt.Errorf("unknown upstream action '%s'", args[0])
+	}
+}
+
+func parseEndpoint(args []string) (rbtransport.Endpoint, error) {
+	var endpoint rbtransport.Endpoint
+	positional := make([]string, 0, 2)
+	for _, arg := range args {
+		if arg == "--krb" {
+			endpoint.UseKrb = true
+			continue
+		}
+		positional = append(positional, arg)
+	}
+
+	if len(positional) == 0 || len(positional) > 2 {
+		return endpoint, fmt.Errorf("expected <url> [proxy-url], got %d arguments", len(positional))
+	}
+	endpoint.URL = strings.TrimSuffix(positional[0], "/")
+	if len(positional) == 2 {
+		endpoint.ProxyURL = positional[1]
+	}
+	return endpoint, nil
+}
+
+func formatUpstreams(statuses []rbtransport.EndpointStatus) string {
+	lines := make([]string, 0, len(statuses))
+	for i, status := range statuses {
+		marker := " "
+		if status.Active {
+			marker = "*"
+		}
+		line := fmt.Sprintf("%s %d %s proxy=%q krb=%t failures=%d", marker, i, status.URL, status.ProxyURL, status.UseKrb, status.Failures)
+		if !status.LastSuccess.IsZero() {
+			line += fmt.Sprintf(" lastSuccess=%s", status.LastSuccess.Format("2006-01-02T15:04:05Z07:00"))
+		}
+		if status.LastError != "" {
+			line += fmt.Sprintf(" lastError=%q", status.LastError)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,89 @@
+package rbevent
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SessionNew    = "session.new"
+	SessionActive = "session.active"
+	SessionLate   = "session.late"
+	SessionDead   = "session.dead"
+	TaskCompleted = "task.completed"
+	TaskFailed    = "task.failed"
+	FileReceived  = "file.received"
+	MacroFinished = "macro.finished"
+)
+
+type Event struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	Time      time.Time      `json:"time"`
+	SessionID string         `json:"sessionId,omitempty"`
+	Data      map[string]any `json:"data,omitempty"`
+}
+
+func NewEvent(eventType, sessionID string, data map[string]any) Event {
+	return Event{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		Time:      time.Now(),
+		SessionID: sessionID,
+		Data:      data,
+	}
+}
+
+// Bus fans events out to subscribers and keeps the most recent ones so the
+// API can show them to operators.
+type Bus struct {
+	subscribers []func(Event)
+	recent      []Event
+	size        int
+	sync.Mutex
+}
+
+func NewBus(size int) *Bus {
+	return &Bus{
+		recent: make([]Event, 0, size),
+		size:   size,
+	}
+}
+
+func (b *Bus) Subscribe(fn func(Event)) {
+	b.Lock()
+	defer b.Unlock()
+	b.subscribers = append(b.subscribers, fn)
+}
+
+func (b *Bus) Publish(e Event) {
+	b.Lock()
+	if len(b.recent) == b.size {
+		copy(b.recent, b.recent[1:])
+		b.recent = b.recent[:b.size-1]
+	}
+	b.recent = append(b.recent, e)
+	subscribers := make([]func(Event), len(b.subscribers))
+	copy(subscribers, b.subscribers)
+	b.Unlock()
+
+	for _, fn := range subscribers {
+		fn(e)
+	}
+}
+
+// Recent returns the buffered events newer than since, oldest first.
+func (b *Bus) Recent(since time.Time) []Event {
+	b.Lock()
+	defer b.Unlock()
+
+	events := make([]Event, 0)
+	for _, e := range b.recent {
+		if e.Time.After(since) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
@@ -0,0 +1,67 @@
+package rbhost
+
+import (
+	"net"
+	"os"
+	"os/user"
+	"runtime"
+)
+
+// Info is the host profile a beacon reports when it registers with the server.
+type Info struct {
+	Hostname    string   `json:"hostname"`
+	OS          string   `json:"os"`
+	Arch        string   `json:"arch"`
+	Kernel      string   `json:"kernel"`
+	Username    string   `json:"username"`
+	UID         string   `json:"uid"`
+	PID         int      `json:"pid"`
+	ProcessPath string   `json:"processPath"`
+	InternalIPs []string `json:"internalIps"`
+	GoVersion   string   `json:"goVersion"`
+	BuildID     string   `json:"buildId"`
+}
+
+// Collect gathers the host profile for the running beacon. Lookups that fail
+// are left empty rather than aborting, since a partial profile is still useful.
+func Collect(buildID string) Info {
+	info := Info{
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		Kernel:      kernelVersion(),
+		PID:         os.Getpid(),
+		InternalIPs: internalIPs(),
+		GoVersion:   runtime.Version(),
+		BuildID:     buildID,
+	}
+
+	if hostname, err := os.Hostname(); err == nil {
+		info.Hostname = hostname
+	}
+	if u, err := user.Current(); err == nil {
+		info.Username = u.Username
+		info.UID = u.Uid
+	}
+	if exe, err := os.Executable(); err == nil {
+		info.ProcessPath = exe
+	}
+
+	return info
+}
+
+func internalIPs() []string {
+	ips := make([]string, 0)
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ips
+	}
+
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		ips = append(ips, ipNet.IP.String())
+	}
+	return ips
+}
@@ -0,0 +1,11 @@
+package rbhost
+
+import "syscall"
+
+func kernelVersion() string {
+	release, err := syscall.Sysctl("kern.osrelease")
+	if err != nil {
+		return ""
+	}
+	return release
+}
@@ -0,0 +1,14 @@
+package rbhost
+
+import (
+	"os"
+	"strings"
+)
+
+func kernelVersion() string {
+	release, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(release))
+}
@@ -0,0 +1,7 @@
+//go:build !linux && !darwin
+
+package rbhost
+
+func kernelVersion() string {
+	return ""
+}
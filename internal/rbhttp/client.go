@@ -3,28 +3,53 @@ package rbhttp
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 )
 
+// StatusError is returned by the generic helpers when the server answers with
+// a non-2xx status code.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("server returned status %d", e.StatusCode)
+}
+
 type HttpClient interface {
 	Get(url string) (*http.Response, error)
 	Post(url string, contentType string, body io.Reader) (*http.Response, error)
 }
 
 type SimpleHttpClient struct {
+	client *http.Client
 }
 
 func (d *SimpleHttpClient) Get(url string) (*http.Response, error) {
-	return http.Get(url)
+	return d.client.Get(url)
 }
 
 func (d *SimpleHttpClient) Post(url string, contentType string, body io.Reader) (*http.Response, error) {
-	return http.Post(url, contentType, body)
+	return d.client.Post(url, contentType, body)
 }
 
 func NewSimpleHttpClient() *SimpleHttpClient {
-	return &SimpleHttpClient{}
+	return &SimpleHttpClient{client: http.DefaultClient}
+}
+
+// NewProxyHttpClient sends every request through an unauthenticated proxy.
+func NewProxyHttpClient(proxyURL string) (*SimpleHttpClient, error) {
+	proxy, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy url '%s': %w", proxyURL, err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxy)
+	return &SimpleHttpClient{client: &http.Client{Transport: transport}}, nil
 }
 
 // Generic helper functions that work with any HttpClient
@@ -36,6 +61,15 @@ func Get[T any](client HttpClient, url string) (*T, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode >= 300 {
+		return nil, &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	// Handle 204 No Content (empty response body)
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
 	var result T
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, err
@@ -55,6 +89,10 @@ func Post[T any](client HttpClient, url string, body any) (*T, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode >= 300 {
+		return nil, &StatusError{StatusCode: resp.StatusCode}
+	}
+
 	// Handle 204 No Content (empty response body)
 	if resp.StatusCode == http.StatusNoContent {
 		return nil, nil
@@ -3,7 +3,9 @@ package rbhttp
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"redbull/internal/rbhost"
 	"sync"
 	"time"
 
@@ -11,18 +13,80 @@ import (
 )
 
 type HttpBody struct {
+	SessionID        string `json:"sessionId"`
+	TaskID           string `json:"taskId"`
 	Command          string `json:"command"`
 	Stdout           string `json:"stdout"`
 	Stderr           string `json:"stderr"`
 	CurrentDirectory string `json:"currentDirectory"`
+	// Failed is set when the command returned an error or was cancelled;
+	// stderr alone can hold warnings from a command that succeeded
+	Failed bool `json:"failed,omitempty"`
 }
 
+// TaskMessage is a single task sent to the beacon. Concurrent tasks run
+// alongside the rest of their batch instead of in order.
+type TaskMessage struct {
+	TaskID     string `json:"taskId"`
+	Command    string `json:"command"`
+	Concurrent bool   `json:"concurrent"`
+}
+
+// CheckInResponse carries the next batch of tasks, along with the IDs of
+// running tasks the operator has cancelled.
 type CheckInResponse struct {
-	Command string `json:"command"`
+	Tasks  []TaskMessage `json:"tasks"`
+	Cancel []string      `json:"cancel,omitempty"`
+}
+
+type ResultsRequest struct {
+	Results []HttpBody `json:"results"`
+}
+
+// PtyOutput is what a beacon terminal printed since the last exchange. Every
+// open terminal is reported on each exchange, even with no new output, so the
+// server knows it is still alive.
+type PtyOutput struct {
+	PtyID    string `json:"ptyId"`
+	Data     []byte `json:"data,omitempty"`
+	Closed   bool   `json:"closed,omitempty"`
+	ExitCode int    `json:"exitCode,omitempty"`
+}
+
+// PtyInput is what the server has for a beacon terminal: keystrokes, a new
+// window size, or a request to close it.
+type PtyInput struct {
+	PtyID string `json:"ptyId"`
+	Data  []byte `json:"data,omitempty"`
+	Cols  int    `json:"cols,omitempty"`
+	Rows  int    `json:"rows,omitempty"`
+	Close bool   `json:"close,omitempty"`
+}
+
+type PtyExchangeRequest struct {
+	SessionID string      `json:"sessionId"`
+	Output    []PtyOutput `json:"output"`
+}
+
+type PtyExchangeResponse struct {
+	Input []PtyInput `json:"input"`
+}
+
+// PtyMessage is what a client attached to a terminal's WebSocket sends:
+// "input", "interrupt", "resize" or "close". Output comes back as binary
+// frames, and the terminal closing as an "exit" message.
+type PtyMessage struct {
+	Type     string `json:"type"`
+	Data     string `json:"data,omitempty"`
+	Cols     int    `json:"cols,omitempty"`
+	Rows     int    `json:"rows,omitempty"`
+	ExitCode int    `json:"exitCode,omitempty"`
+	Error    string `json:"error,omitempty"`
 }
 
 type NewCommandResponse struct {
-	Success bool `json:"success"`
+	Success bool   `json:"success"`
+	TaskID  string `json:"taskId"`
 }
 
 type ErrorResponse struct {
@@ -30,7 +94,79 @@ type ErrorResponse struct {
 }
 
 type NewCommandRequest struct {
-	Command string `json:"command"`
+	SessionID  string `json:"sessionId"`
+	Command    string `json:"command"`
+	Concurrent bool   `json:"concurrent"`
+	Priority   int    `json:"priority"`
+	Operator   string `json:"operator"`
+}
+
+type SetPriorityRequest struct {
+	Priority int `json:"priority"`
+}
+
+type CancelTaskResponse struct {
+	TaskID string `json:"taskId"`
+	State  string `json:"state"`
+}
+
+type ClearTasksResponse struct {
+	Cleared int `json:"cleared"`
+}
+
+type RunMacroRequest struct {
+	SessionID string            `json:"sessionId"`
+	Args      map[string]string `json:"args"`
+	Operator  string            `json:"operator"`
+}
+
+type RefreshPathRequest struct {
+	Path     string `json:"path"`
+	Depth    int    `json:"depth"`
+	Operator string `json:"operator"`
+}
+
+type DownloadPathRequest struct {
+	Path     string `json:"path"`
+	Format   string `json:"format"`
+	Operator string `json:"operator"`
+}
+
+type OpenPtyRequest struct {
+	Shell    string `json:"shell"`
+	Cols     int    `json:"cols"`
+	Rows     int    `json:"rows"`
+	Operator string `json:"operator"`
+}
+
+type RegisterRequest struct {
+	SessionID string      `json:"sessionId"`
+	SleepTime int         `json:"sleepTime"`
+	Host      rbhost.Info `json:"host"`
+}
+
+type RegisterResponse struct {
+	SessionID string `json:"sessionId"`
+}
+
+type SessionOverview struct {
+	ID             string    `json:"id"`
+	Hostname       string    `json:"hostname"`
+	Username       string    `json:"username"`
+	Health         string    `json:"health"`
+	SleepTime      int       `json:"sleepTime"`
+	LastCheckIn    time.Time `json:"lastCheckIn"`
+	SinceCheckInMs int64     `json:"sinceCheckInMs"`
+	MissedCheckIns float64   `json:"missedCheckIns"`
+}
+
+type SessionsOverviewResponse struct {
+	Counts   map[string]int    `json:"counts"`
+	Sessions []SessionOverview `json:"sessions"`
+}
+
+type FileSavedResponse struct {
+	Filename string `json:"filename"`
 }
 
 type CheckInTimeResponse struct {
@@ -45,6 +181,53 @@ func (h *HttpBody) Bind(r *http.Request) error {
 	return nil
 }
 
+func (rr *ResultsRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func (s *SetPriorityRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func (rm *RunMacroRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func (rp *RefreshPathRequest) Bind(r *http.Request) error {
+	if rp.Depth < 0 {
+		return errors.New("depth must not be negative")
+	}
+	return nil
+}
+
+func (dp *DownloadPathRequest) Bind(r *http.Request) error {
+	if dp.Path == "" {
+		return errors.New("path is required")
+	}
+	return nil
+}
+
+func (pe *PtyExchangeRequest) Bind(r *http.Request) error {
+	if pe.SessionID == "" {
+		return errors.New("sessionId is required")
+	}
+	return nil
+}
+
+func (op *OpenPtyRequest) Bind(r *http.Request) error {
+	if op.Cols < 0 || op.Rows < 0 {
+		return errors.New("cols and rows must not be negative")
+	}
+	return nil
+}
+
+func (rr *RegisterRequest) Bind(r *http.Request) error {
+	if rr.SessionID == "" {
+		return errors.New("sessionId is required")
+	}
+	return nil
+}
+
 // EncodeCommand encodes a command string as base64 for transport to the beacon.
 func EncodeCommand(cmd string) string {
 	return base64.StdEncoding.EncodeToString([]byte(cmd))
@@ -64,16 +247,26 @@ func ReadJsonBody(r *http.Request) (HttpBody, error) {
 
 type BeaconResponse struct {
 	ID               string    `json:"id"`
+	SessionID        string    `json:"sessionId"`
+	TaskID           string    `json:"taskId"`
 	Time             time.Time `json:"time"`
 	Stdout           string    `json:"stdout"`
 	Stderr           string    `json:"stderr"`
 	Command          string    `json:"command"`
 	CurrentDirectory string    `json:"currentDirectory"`
+	Operator         string    `json:"operator,omitempty"`
+	Failed           bool      `json:"failed,omitempty"`
+	// Parsed is the structured form of Stdout, set when the server recognises
+	// the output, and ParsedKind its kind, e.g. "processes", so responses can
+	// be filtered without decoding it
+	Parsed     json.RawMessage `json:"parsed,omitempty"`
+	ParsedKind string          `json:"-"`
 }
 
-func NewBeaconResponse(cmd, stdout, stderr, currentDirectory string) *BeaconResponse {
+func NewBeaconResponse(sessionID, cmd, stdout, stderr, currentDirectory string) *BeaconResponse {
 	return &BeaconResponse{
 		ID:               uuid.New().String(),
+		SessionID:        sessionID,
 		Time:             time.Now(),
 		Stdout:           stdout,
 		Stderr:           stderr,
@@ -82,17 +275,43 @@ func NewBeaconResponse(cmd, stdout, stderr, currentDirectory string) *BeaconResp
 	}
 }
 
+// Finding is the summary of the latest parsed output of one kind for a
+// session, for reports.
+type Finding struct {
+	Kind       string    `json:"kind"`
+	Parser     string    `json:"parser"`
+	Summary    string    `json:"summary"`
+	ResponseID string    `json:"responseId"`
+	TaskID     string    `json:"taskId,omitempty"`
+	Command    string    `json:"command"`
+	Time       time.Time `json:"time"`
+}
+
 type BeaconResponses struct {
 	Responses []BeaconResponse
+	tasks     map[string]struct{}
 	sync.Mutex
 }
 
 func NewBeaconResponses() *BeaconResponses {
 	return &BeaconResponses{
 		Responses: make([]BeaconResponse, 0),
+		tasks:     make(map[string]struct{}),
 	}
 }
 
-func (b *BeaconResponses) Append(r BeaconResponse) {
+// Append stores the response unless a response for the same task has already
+// been stored, and reports whether it was added.
+func (b *BeaconResponses) Append(r BeaconResponse) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	if r.TaskID != "" {
+		if _, ok := b.tasks[r.TaskID]; ok {
+			return false
+		}
+		b.tasks[r.TaskID] = struct{}{}
+	}
 	b.Responses = append(b.Responses, r)
+	return true
 }
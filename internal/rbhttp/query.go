@@ -0,0 +1,98 @@
+package rbhttp
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ResponseFilter selects stored responses. Empty fields match everything.
+type ResponseFilter struct {
+	SessionID string
+	TaskID    string
+	Operator  string
+	// CommandName matches the first word of the command, e.g. "shell"
+	CommandName string
+	From        time.Time
+	To          time.Time
+	// Since matches responses stored strictly after it, for incremental fetches
+	Since time.Time
+	// Text is searched for case-insensitively in stdout and stderr
+	Text string
+	// Kind matches the kind of parsed output, e.g. "processes"
+	Kind string
+}
+
+func (f ResponseFilter) Match(r BeaconResponse) bool {
+	if f.SessionID != "" && r.SessionID != f.SessionID {
+		return false
+	}
+	if f.TaskID != "" && r.TaskID != f.TaskID {
+		return false
+	}
+	if f.Operator != "" && r.Operator != f.Operator {
+		return false
+	}
+	if f.CommandName != "" && commandName(r.Command) != f.CommandName {
+		return false
+	}
+	if f.Kind != "" && r.ParsedKind != f.Kind {
+		return false
+	}
+	if !f.From.IsZero() && r.Time.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && r.Time.After(f.To) {
+		return false
+	}
+	if !f.Since.IsZero() && !r.Time.After(f.Since) {
+		return false
+	}
+	if f.Text != "" {
+		text := strings.ToLower(f.Text)
+		if !strings.Contains(strings.ToLower(r.Stdout), text) && !strings.Contains(strings.ToLower(r.Stderr), text) {
+			return false
+		}
+	}
+	return true
+}
+
+func commandName(command string) string {
+	name, _, _ := strings.Cut(strings.TrimSpace(command), " ")
+	return name
+}
+
+// Query returns the responses matching filter, oldest first, starting after
+// cursor. At most limit responses are returned, or all of them if limit is 0.
+// The returned cursor fetches the next page and is empty on the last one.
+//
+// Responses are only ever appended, so a cursor is simply the position to
+// resume scanning from.
+func (b *BeaconResponses) Query(filter ResponseFilter, cursor string, limit int) ([]BeaconResponse, string, error) {
+	start := 0
+	if cursor != "" {
+		n, err := strconv.Atoi(cursor)
+		if err != nil || n < 0 {
+			return nil, "", ErrInvalidCursor
+		}
+		start = n
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	matched := make([]BeaconResponse, 0)
+	for i := start; i < len(b.Responses); i++ {
+		if !filter.Match(b.Responses[i]) {
+			continue
+		}
+		if limit > 0 && len(matched) == limit {
+			return matched, strconv.Itoa(i), nil
+		}
+		matched = append(matched, b.Responses[i])
+	}
+	return matched, "", nil
+}
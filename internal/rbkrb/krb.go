@@ -0,0 +1,203 @@
+package rbkrb
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/jcmturner/gokrb5/v8/client"
+	krbconfig "github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+)
+
+const userAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/142.0.0.0 Safari/537.36"
+
+// KrbHttpClient is an HttpClient that authenticates to an HTTP proxy with
+// SPNEGO (Negotiate), using either the host's credential cache or a keytab.
+type KrbHttpClient struct {
+	ProxyURL string
+	// Keytab and Principal (user@REALM) select keytab login; when Keytab is
+	// empty the credential cache from KRB5CCNAME is used.
+	Keytab    string
+	Principal string
+
+	proxy *url.URL
+	http  *http.Client
+	krb   *client.Client
+	sync.Mutex
+}
+
+func NewKrbHttpClient(proxyURL, keytabPath, principal string) (*KrbHttpClient, error) {
+	k := &KrbHttpClient{
+		ProxyURL:  proxyURL,
+		Keytab:    keytabPath,
+		Principal: principal,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxyURL != "" {
+		proxy, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url '%s': %w", proxyURL, err)
+		}
+		k.proxy = proxy
+		transport.Proxy = http.ProxyURL(proxy)
+		// HTTPS upstreams are tunnelled with CONNECT, which carries its own headers
+		transport.GetProxyConnectHeader = func(_ context.Context, _ *url.URL, _ string) (http.Header, error) {
+			negotiate, err := k.negotiate()
+			if err != nil {
+				return nil, err
+			}
+			return http.Header{"Proxy-Authorization": {negotiate}, "User-Agent": {userAgent}}, nil
+		}
+	}
+
+	k.http = &http.Client{Transport: &negotiateRoundTripper{client: k, next: transport}}
+	return k, nil
+}
+
+func (k *KrbHttpClient) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return k.http.Do(req)
+}
+
+func (k *KrbHttpClient) Post(url string, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return k.http.Do(req)
+}
+
+// negotiate returns a fresh "Negotiate <token>" value for the proxy.
+func (k *KrbHttpClient) negotiate() (string, error) {
+	cl, err := k.client()
+	if err != nil {
+		return "", err
+	}
+
+	s := spnego.SPNEGOClient(cl, "HTTP/"+k.proxy.Hostname())
+	if err := s.AcquireCred(); err != nil {
+		k.reset()
+		return "", fmt.Errorf("failed to acquire kerberos credentials: %w", err)
+	}
+	token, err := s.InitSecContext()
+	if err != nil {
+		k.reset()
+		return "", fmt.Errorf("failed to initialise spnego context: %w", err)
+	}
+	tokenBytes, err := token.Marshal()
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal spnego token: %w", err)
+	}
+
+	return "Negotiate " + base64.StdEncoding.EncodeToString(tokenBytes), nil
+}
+
+// client lazily creates the Kerberos client, so a beacon started before the
+// user has a ticket picks one up once it appears.
+func (k *KrbHttpClient) client() (*client.Client, error) {
+	k.Lock()
+	defer k.Unlock()
+
+	if k.krb != nil {
+		return k.krb, nil
+	}
+
+	conf, err := loadKrb5Conf()
+	if err != nil {
+		return nil, err
+	}
+
+	if k.Keytab != "" {
+		kt, err := keytab.Load(k.Keytab)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load keytab '%s': %w", k.Keytab, err)
+		}
+		username, realm, ok := strings.Cut(k.Principal, "@")
+		if !ok {
+			return nil, fmt.Errorf("principal '%s' must be in the form user@REALM", k.Principal)
+		}
+		k.krb = client.NewWithKeytab(username, realm, kt, conf, client.DisablePAFXFAST(true))
+		return k.krb, nil
+	}
+
+	ccache, err := credentials.LoadCCache(ccachePath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credential cache: %w", err)
+	}
+	k.krb, err = client.NewFromCCache(ccache, conf, client.DisablePAFXFAST(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kerberos client: %w", err)
+	}
+	return k.krb, nil
+}
+
+// reset drops the cached Kerberos client so the next request reloads the
+// keytab or credential cache, e.g. after the user renews their ticket.
+func (k *KrbHttpClient) reset() {
+	k.Lock()
+	defer k.Unlock()
+
+	if k.krb != nil {
+		k.krb.Destroy()
+		k.krb = nil
+	}
+}
+
+func loadKrb5Conf() (*krbconfig.Config, error) {
+	path := os.Getenv("KRB5_CONFIG")
+	if path == "" {
+		path = "/etc/krb5.conf"
+	}
+	conf, err := krbconfig.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kerberos config '%s': %w", path, err)
+	}
+	return conf, nil
+}
+
+func ccachePath() string {
+	if name := os.Getenv("KRB5CCNAME"); name != "" {
+		return strings.TrimPrefix(name, "FILE:")
+	}
+	return fmt.Sprintf("/tmp/krb5cc_%d", os.Getuid())
+}
+
+// negotiateRoundTripper attaches a fresh Negotiate token to every plain HTTP
+// request sent through the proxy, including ones made while following
+// redirects, since proxies reject replayed tokens.
+type negotiateRoundTripper struct {
+	client *KrbHttpClient
+	next   http.RoundTripper
+}
+
+func (n *negotiateRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", userAgent)
+
+	if n.client.proxy != nil && req.URL.Scheme == "http" {
+		negotiate, err := n.client.negotiate()
+		if err != nil {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, err
+		}
+		req.Header.Set("Proxy-Authorization", negotiate)
+	}
+
+	return n.next.RoundTrip(req)
+}
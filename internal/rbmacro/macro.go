@@ -0,0 +1,109 @@
+package rbmacro
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMacroNotFound = errors.New("macro not found")
+	ErrRunNotFound   = errors.New("macro run not found")
+)
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// placeholder matches a {{param}} reference in a step.
+var placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_-]+)\s*\}\}`)
+
+// Param is a value substituted into a macro's steps wherever {{name}}
+// appears. A param without a default must be given when the macro is run.
+type Param struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// Macro is a named sequence of beacon commands. With StopOnFailure the steps
+// are queued one at a time and the run stops at the first step whose command
+// fails; otherwise every step is queued at once. AutoRun macros
+// are started against every newly registered session.
+type Macro struct {
+	Name          string    `json:"name"`
+	Description   string    `json:"description,omitempty"`
+	Params        []Param   `json:"params,omitempty"`
+	Steps         []string  `json:"steps"`
+	StopOnFailure bool      `json:"stopOnFailure"`
+	AutoRun       bool      `json:"autoRun"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// Validate checks the macro can be run: its name is usable in a URL, it has
+// steps, and every {{param}} it references is declared. Auto-run macros have
+// nobody to supply parameters, so all of theirs must be optional.
+func (m *Macro) Validate() error {
+	if !namePattern.MatchString(m.Name) {
+		return errors.New("macro names may only contain letters, digits, '-' and '_'")
+	}
+	if len(m.Steps) == 0 {
+		return errors.New("a macro needs at least one step")
+	}
+
+	declared := make(map[string]Param)
+	for _, param := range m.Params {
+		if !namePattern.MatchString(param.Name) {
+			return fmt.Errorf("invalid param name '%s'", param.Name)
+		}
+		if _, ok := declared[param.Name]; ok {
+			return fmt.Errorf("param '%s' is declared twice", param.Name)
+		}
+		if m.AutoRun && param.Required {
+			return fmt.Errorf("auto-run macros cannot have required params, but '%s' is", param.Name)
+		}
+		declared[param.Name] = param
+	}
+
+	for i, step := range m.Steps {
+		if strings.TrimSpace(step) == "" {
+			return fmt.Errorf("step %d is empty", i+1)
+		}
+		for _, match := range placeholder.FindAllStringSubmatch(step, -1) {
+			if _, ok := declared[match[1]]; !ok {
+				return fmt.Errorf("step %d uses undeclared param '%s'", i+1, match[1])
+			}
+		}
+	}
+	return nil
+}
+
+// Expand returns the macro's steps with args substituted for their params,
+// falling back to the params' defaults.
+func (m *Macro) Expand(args map[string]string) ([]string, error) {
+	values := make(map[string]string, len(m.Params))
+	for _, param := range m.Params {
+		value, ok := args[param.Name]
+		if !ok {
+			if param.Required {
+				return nil, fmt.Errorf("missing required param '%s'", param.Name)
+			}
+			value = param.Default
+		}
+		values[param.Name] = value
+	}
+	for name := range args {
+		if _, ok := values[name]; !ok {
+			return nil, fmt.Errorf("unknown param '%s'", name)
+		}
+	}
+
+	commands := make([]string, 0, len(m.Steps))
+	for _, step := range m.Steps {
+		commands = append(commands, placeholder.ReplaceAllStringFunc(step, func(ref string) string {
+			return values[placeholder.FindStringSubmatch(ref)[1]]
+		}))
+	}
+	return commands, nil
+}
@@ -0,0 +1,231 @@
+package rbmacro
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RunRunning   = "running"
+	RunCompleted = "completed"
+	RunFailed    = "failed"
+	RunCancelled = "cancelled"
+)
+
+const (
+	StepPending   = "pending"
+	StepQueued    = "queued"
+	StepSucceeded = "succeeded"
+	StepFailed    = "failed"
+	StepSkipped   = "skipped"
+)
+
+// QueueFunc queues a command for the run's session on behalf of operator and
+// returns the task ID.
+type QueueFunc func(command, operator string) string
+
+// Step is one command of a run and the task it was queued as.
+type Step struct {
+	Command string `json:"command"`
+	TaskID  string `json:"taskId,omitempty"`
+	State   string `json:"state"`
+}
+
+// Run is a macro being worked through on a session. The commands are expanded
+// when the run starts, so later edits to the macro do not affect it.
+type Run struct {
+	ID            string     `json:"id"`
+	Macro         string     `json:"macro"`
+	SessionID     string     `json:"sessionId"`
+	Operator      string     `json:"operator,omitempty"`
+	AutoRun       bool       `json:"autoRun"`
+	StopOnFailure bool       `json:"stopOnFailure"`
+	Steps         []Step     `json:"steps"`
+	State         string     `json:"state"`
+	StartedAt     time.Time  `json:"startedAt"`
+	FinishedAt    *time.Time `json:"finishedAt,omitempty"`
+}
+
+func (r *Run) copy() Run {
+	c := *r
+	c.Steps = append([]Step(nil), r.Steps...)
+	return c
+}
+
+// queueNext queues the steps that can go out now: the next pending step when
+// stopping on failure, or every pending step otherwise.
+func (r *Run) queueNext(queue QueueFunc) []string {
+	queued := make([]string, 0)
+	for i := range r.Steps {
+		step := &r.Steps[i]
+		if step.State == StepQueued && r.StopOnFailure {
+			break
+		}
+		if step.State != StepPending {
+			continue
+		}
+		step.TaskID = queue(step.Command, r.Operator)
+		step.State = StepQueued
+		queued = append(queued, step.TaskID)
+		if r.StopOnFailure {
+			break
+		}
+	}
+	return queued
+}
+
+func (r *Run) finish(state string) {
+	now := time.Now()
+	r.State = state
+	r.FinishedAt = &now
+	for i := range r.Steps {
+		if r.Steps[i].State == StepPending {
+			r.Steps[i].State = StepSkipped
+		}
+	}
+}
+
+// Runs tracks macro runs, and the tasks queued for them so results can move
+// each run along.
+type Runs struct {
+	runs  map[string]*Run
+	tasks map[string]string
+	sync.Mutex
+}
+
+func NewRuns() *Runs {
+	return &Runs{
+		runs:  make(map[string]*Run),
+		tasks: make(map[string]string),
+	}
+}
+
+// Start begins running the expanded commands of m on the session, queueing
+// the first steps with queue.
+func (r *Runs) Start(m Macro, commands []string, sessionID, operator string, autoRun bool, queue QueueFunc) Run {
+	run := &Run{
+		ID:            uuid.New().String(),
+		Macro:         m.Name,
+		SessionID:     sessionID,
+		Operator:      operator,
+		AutoRun:       autoRun,
+		StopOnFailure: m.StopOnFailure,
+		Steps:         make([]Step, 0, len(commands)),
+		State:         RunRunning,
+		StartedAt:     time.Now(),
+	}
+	for _, command := range commands {
+		run.Steps = append(run.Steps, Step{Command: command, State: StepPending})
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.runs[run.ID] = run
+	for _, taskID := range run.queueNext(queue) {
+		r.tasks[taskID] = run.ID
+	}
+	return run.copy()
+}
+
+// StepDone records the outcome of a task and queues whatever the run should
+// do next. It returns the run and true when this step finished the run.
+func (r *Runs) StepDone(taskID string, failed bool, queue QueueFunc) (Run, bool) {
+	r.Lock()
+	defer r.Unlock()
+
+	runID, ok := r.tasks[taskID]
+	if !ok {
+		return Run{}, false
+	}
+	delete(r.tasks, taskID)
+	run := r.runs[runID]
+
+	for i := range run.Steps {
+		if run.Steps[i].TaskID == taskID {
+			run.Steps[i].State = StepSucceeded
+			if failed {
+				run.Steps[i].State = StepFailed
+			}
+			break
+		}
+	}
+	if run.State != RunRunning {
+		return run.copy(), false
+	}
+
+	if failed && run.StopOnFailure {
+		run.finish(RunFailed)
+		return run.copy(), true
+	}
+	for _, queuedID := range run.queueNext(queue) {
+		r.tasks[queuedID] = run.ID
+	}
+
+	state := RunCompleted
+	for _, step := range run.Steps {
+		switch step.State {
+		case StepPending, StepQueued:
+			return run.copy(), false
+		case StepFailed:
+			state = RunFailed
+		}
+	}
+	run.finish(state)
+	return run.copy(), true
+}
+
+// Cancel stops the run, skipping its pending steps and calling cancel for each
+// task that has been queued but has not returned.
+func (r *Runs) Cancel(runID string, cancel func(taskID string)) (Run, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	run, ok := r.runs[runID]
+	if !ok {
+		return Run{}, ErrRunNotFound
+	}
+	if run.State != RunRunning {
+		return run.copy(), nil
+	}
+
+	for _, step := range run.Steps {
+		if step.State == StepQueued {
+			cancel(step.TaskID)
+		}
+	}
+	run.finish(RunCancelled)
+	return run.copy(), nil
+}
+
+func (r *Runs) Get(runID string) (Run, bool) {
+	r.Lock()
+	defer r.Unlock()
+
+	run, ok := r.runs[runID]
+	if !ok {
+		return Run{}, false
+	}
+	return run.copy(), true
+}
+
+// List returns the runs on the session, or on every session if sessionID is
+// empty, most recent first.
+func (r *Runs) List(sessionID string) []Run {
+	r.Lock()
+	defer r.Unlock()
+
+	list := make([]Run, 0)
+	for _, run := range r.runs {
+		if sessionID == "" || run.SessionID == sessionID {
+			list = append(list, run.copy())
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].StartedAt.After(list[j].StartedAt)
+	})
+	return list
+}
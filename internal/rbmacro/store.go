@@ -0,0 +1,160 @@
+package rbmacro
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store holds the server's macros. They are written to a JSON file after
+// every change so they survive a server restart.
+type Store struct {
+	path   string
+	macros map[string]Macro
+	sync.Mutex
+}
+
+// NewStore creates a store backed by the file at path, loading any macros
+// saved by a previous run.
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		path:   path,
+		macros: make(map[string]Macro),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Put validates and saves the macro, replacing any macro with the same name.
+func (s *Store) Put(m Macro) (Macro, error) {
+	if err := m.Validate(); err != nil {
+		return Macro{}, err
+	}
+	m.UpdatedAt = time.Now()
+
+	s.Lock()
+	defer s.Unlock()
+
+	previous, existed := s.macros[m.Name]
+	s.macros[m.Name] = m
+	if err := s.save(); err != nil {
+		if existed {
+			s.macros[m.Name] = previous
+		} else {
+			delete(s.macros, m.Name)
+		}
+		return Macro{}, err
+	}
+	return m, nil
+}
+
+func (s *Store) Get(name string) (Macro, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	m, ok := s.macros[name]
+	return m, ok
+}
+
+func (s *Store) Delete(name string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	m, ok := s.macros[name]
+	if !ok {
+		return ErrMacroNotFound
+	}
+	delete(s.macros, name)
+	if err := s.save(); err != nil {
+		s.macros[name] = m
+		return err
+	}
+	return nil
+}
+
+// List returns every macro, sorted by name.
+func (s *Store) List() []Macro {
+	s.Lock()
+	defer s.Unlock()
+
+	list := make([]Macro, 0, len(s.macros))
+	for _, m := range s.macros {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// AutoRun returns the macros to start on newly registered sessions, sorted by
+// name so they are queued in a predictable order.
+func (s *Store) AutoRun() []Macro {
+	list := make([]Macro, 0)
+	for _, m := range s.List() {
+		if m.AutoRun {
+			list = append(list, m)
+		}
+	}
+	return list
+}
+
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read macros: %w", err)
+	}
+
+	var list []Macro
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("failed to decode macros: %w", err)
+	}
+	for _, m := range list {
+		s.macros[m.Name] = m
+	}
+	return nil
+}
+
+// save writes the macros to a temporary file and renames it into place, so a
+// crash mid-write never leaves a corrupt file behind.
+func (s *Store) save() error {
+	list := make([]Macro, 0, len(s.macros))
+	for _, m := range s.macros {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode macros: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".macros-*")
+	if err != nil {
+		return fmt.Errorf("failed to write macros: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write macros: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write macros: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write macros: %w", err)
+	}
+	return nil
+}
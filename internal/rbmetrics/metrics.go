@@ -0,0 +1,247 @@
+package rbmetrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// DefaultBuckets suit latencies in seconds, from well under a second up to
+// beacons that sleep for several minutes.
+var DefaultBuckets = []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800}
+
+// Registry holds metric families and writes them in the Prometheus text
+// exposition format.
+type Registry struct {
+	families []*family
+	onScrape []func()
+	sync.Mutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// OnScrape registers fn to run before every scrape, to refresh gauges that
+// are cheaper to compute on demand than to keep up to date.
+func (r *Registry) OnScrape(fn func()) {
+	r.Lock()
+	defer r.Unlock()
+	r.onScrape = append(r.onScrape, fn)
+}
+
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	return &Counter{r.register(name, help, typeCounter, labels, nil)}
+}
+
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{r.register(name, help, typeGauge, labels, nil)}
+}
+
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Histogram{r.register(name, help, typeHistogram, labels, buckets)}
+}
+
+func (r *Registry) register(name, help, metricType string, labels []string, buckets []float64) *family {
+	f := &family{
+		name:    name,
+		help:    help,
+		kind:    metricType,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	r.families = append(r.families, f)
+	return f
+}
+
+// WriteTo refreshes the on-demand gauges and writes every metric to w.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.Lock()
+	onScrape := append([]func(){}, r.onScrape...)
+	families := append([]*family{}, r.families...)
+	r.Unlock()
+
+	for _, fn := range onScrape {
+		fn()
+	}
+
+	var b strings.Builder
+	for _, f := range families {
+		f.write(&b)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+type Counter struct{ *family }
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increases the counter. Counters only go up, so negative values are
+// ignored.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	c.update(labelValues, func(s *series) { s.value += v })
+}
+
+type Gauge struct{ *family }
+
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.update(labelValues, func(s *series) { s.value = v })
+}
+
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.update(labelValues, func(s *series) { s.value += v })
+}
+
+// Replace swaps every series for the ones fill adds to, so label values that
+// no longer exist, such as forgotten sessions, stop being reported. The new
+// series are built apart and swapped in at once, so a concurrent scrape never
+// sees the gauge half rebuilt.
+func (g *Gauge) Replace(fill func(add func(v float64, labelValues ...string))) {
+	fresh := make(map[string]*series)
+	fill(func(v float64, labelValues ...string) {
+		g.seriesIn(fresh, labelValues).value += v
+	})
+
+	g.Lock()
+	defer g.Unlock()
+	g.series = fresh
+}
+
+type Histogram struct{ *family }
+
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.update(labelValues, func(s *series) {
+		if s.counts == nil {
+			s.counts = make([]uint64, len(h.buckets))
+		}
+		for i, bound := range h.buckets {
+			if v <= bound {
+				s.counts[i]++
+			}
+		}
+		s.count++
+		s.sum += v
+	})
+}
+
+type family struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+	series  map[string]*series
+	sync.Mutex
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+func (f *family) update(labelValues []string, fn func(*series)) {
+	f.Lock()
+	defer f.Unlock()
+	fn(f.seriesIn(f.series, labelValues))
+}
+
+// seriesIn finds the series for labelValues in m, adding it if missing.
+func (f *family) seriesIn(m map[string]*series, labelValues []string) *series {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s takes %d labels, got %d", f.name, len(f.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+
+	s, ok := m[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		m[key] = s
+	}
+	return s
+}
+
+func (f *family) write(b *strings.Builder) {
+	f.Lock()
+	defer f.Unlock()
+
+	fmt.Fprintf(b, "# HELP %s %s\n", f.name, helpEscaper.Replace(f.help))
+	fmt.Fprintf(b, "# TYPE %s %s\n", f.name, f.kind)
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+		if f.kind != typeHistogram {
+			fmt.Fprintf(b, "%s%s %s\n", f.name, f.labelSet(s.labelValues, ""), formatValue(s.value))
+			continue
+		}
+
+		for i, bound := range f.buckets {
+			fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, f.labelSet(s.labelValues, formatValue(bound)), s.counts[i])
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, f.labelSet(s.labelValues, "+Inf"), s.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", f.name, f.labelSet(s.labelValues, ""), formatValue(s.sum))
+		fmt.Fprintf(b, "%s_count%s %d\n", f.name, f.labelSet(s.labelValues, ""), s.count)
+	}
+}
+
+// labelSet renders {name="value",...}, adding le for histogram buckets.
+func (f *family) labelSet(values []string, le string) string {
+	pairs := make([]string, 0, len(values)+1)
+	for i, name := range f.labels {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, labelEscaper.Replace(values[i])))
+	}
+	if le != "" {
+		pairs = append(pairs, fmt.Sprintf(`le="%s"`, le))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
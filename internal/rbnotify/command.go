@@ -0,0 +1,59 @@
+package rbnotify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"redbull/internal/rbevent"
+	"strings"
+	"time"
+)
+
+const commandTimeout = 30 * time.Second
+
+// Command runs a local command for each event, with the event as JSON on
+// stdin and its type, ID and session in RB_EVENT_TYPE, RB_EVENT_ID and
+// RB_SESSION_ID.
+type Command struct {
+	argv []string
+}
+
+// NewCommand creates a command sink. The command is run directly, not through
+// a shell.
+func NewCommand(argv []string) (*Command, error) {
+	if len(argv) == 0 {
+		return nil, errors.New("a command hook needs a command to run")
+	}
+	return &Command{argv: argv}, nil
+}
+
+func (c *Command) Name() string {
+	return "command " + strings.Join(c.argv, " ")
+}
+
+func (c *Command) Send(e rbevent.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, c.argv[0], c.argv[1:]...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"RB_EVENT_TYPE="+e.Type,
+		"RB_EVENT_ID="+e.ID,
+		"RB_SESSION_ID="+e.SessionID,
+	)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("command hook failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package rbnotify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"redbull/internal/rbevent"
+	"sync"
+)
+
+// File appends each event to a file as a line of JSON.
+type File struct {
+	path string
+	file *os.File
+	sync.Mutex
+}
+
+func NewFile(path string) (*File, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %w", err)
+	}
+	return &File{path: path, file: file}, nil
+}
+
+func (f *File) Name() string {
+	return "file " + f.path
+}
+
+func (f *File) Send(e rbevent.Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	if _, err := f.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write event log: %w", err)
+	}
+	return nil
+}
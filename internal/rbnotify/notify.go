@@ -0,0 +1,118 @@
+package rbnotify
+
+import (
+	"errors"
+	"fmt"
+	"redbull/internal/rbevent"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// queueSize is how many events a sink can fall behind by before new ones are
+// dropped.
+const queueSize = 256
+
+// Sink delivers events somewhere outside the server.
+type Sink interface {
+	Name() string
+	Send(e rbevent.Event) error
+}
+
+// SinkConfig describes a sink. Type is "webhook" (URL, with an optional HMAC
+// Secret), "command" (Command) or "file" (Path). Events lists the event types
+// to send, such as "session.new" or "task.*"; leave it empty to send every
+// event. It matches config.NotifySink field for field, so the server config
+// converts to it without the config package, which beacons link too,
+// importing the sinks.
+type SinkConfig struct {
+	Type    string
+	Events  []string
+	URL     string
+	Secret  string
+	Command []string
+	Path    string
+}
+
+// NewSink builds the sink a SinkConfig describes.
+func NewSink(cfg SinkConfig) (Sink, error) {
+	switch cfg.Type {
+	case "webhook":
+		if cfg.URL == "" {
+			return nil, errors.New("a webhook needs a URL")
+		}
+		return NewWebhook(cfg.URL, cfg.Secret), nil
+	case "command":
+		return NewCommand(cfg.Command)
+	case "file":
+		return NewFile(cfg.Path)
+	default:
+		return nil, fmt.Errorf("unknown sink type '%s'", cfg.Type)
+	}
+}
+
+// Dispatcher hands events to sinks. Each sink has its own queue and worker,
+// so a slow webhook never holds up the server or the other sinks.
+type Dispatcher struct {
+	routes []*route
+}
+
+type route struct {
+	sink   Sink
+	events []string
+	queue  chan rbevent.Event
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{}
+}
+
+// Add sends events of the given types to the sink. A type ending in "*"
+// matches by prefix, so "session.*" covers every session event; no types at
+// all matches everything.
+func (d *Dispatcher) Add(sink Sink, events []string) {
+	r := &route{
+		sink:   sink,
+		events: events,
+		queue:  make(chan rbevent.Event, queueSize),
+	}
+	d.routes = append(d.routes, r)
+
+	go func() {
+		for e := range r.queue {
+			if err := r.sink.Send(e); err != nil {
+				zap.L().Error("Failed to deliver event", zap.Error(err), zap.String("sink", r.sink.Name()), zap.String("event", e.Type))
+			}
+		}
+	}()
+}
+
+// Publish queues the event for every sink that wants it. It is meant to be
+// subscribed to an rbevent.Bus.
+func (d *Dispatcher) Publish(e rbevent.Event) {
+	for _, r := range d.routes {
+		if !r.matches(e.Type) {
+			continue
+		}
+		select {
+		case r.queue <- e:
+		default:
+			zap.L().Warn("Dropped event for slow sink", zap.String("sink", r.sink.Name()), zap.String("event", e.Type))
+		}
+	}
+}
+
+func (r *route) matches(eventType string) bool {
+	if len(r.events) == 0 {
+		return true
+	}
+	for _, pattern := range r.events {
+		if pattern == "*" || pattern == eventType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package rbnotify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"redbull/internal/rbevent"
+	"strconv"
+	"time"
+)
+
+const (
+	webhookAttempts = 3
+	webhookTimeout  = 10 * time.Second
+)
+
+// SignatureHeader carries the hex HMAC-SHA256, keyed with the webhook's
+// secret, of the TimestampHeader value, a ".", and the request body, as
+// "sha256=<hex>". Covering the timestamp stops a captured delivery being
+// replayed under a fresh one, so receivers should check the signature and
+// then reject timestamps more than a few minutes old.
+const SignatureHeader = "X-Redbull-Signature"
+
+// TimestampHeader is when the request was sent, in Unix seconds. Retries
+// carry a new timestamp; the event's own time is in the body.
+const TimestampHeader = "X-Redbull-Timestamp"
+
+// Webhook POSTs each event as JSON to a URL, retrying failed deliveries a few
+// times with a growing delay.
+type Webhook struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhook creates a webhook sink. Requests are signed when secret is not
+// empty.
+func NewWebhook(url, secret string) *Webhook {
+	return &Webhook{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+func (w *Webhook) Name() string {
+	return "webhook " + w.url
+}
+
+func (w *Webhook) Send(e rbevent.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = w.post(e, body)
+		if err == nil || attempt == webhookAttempts {
+			return err
+		}
+		time.Sleep(time.Duration(attempt) * time.Second)
+	}
+}
+
+func (w *Webhook) post(e rbevent.Event, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Redbull-Event", e.Type)
+	req.Header.Set("X-Redbull-Delivery", e.ID)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(TimestampHeader, timestamp)
+	if len(w.secret) > 0 {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(w.secret, timestamp, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of timestamp + "." + body, for receivers
+// to compare against the signature header.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,195 @@
+package rboutbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"redbull/internal/rbhttp"
+	"sync"
+)
+
+// Outbox holds task results the beacon has not yet delivered to the server.
+// It is bounded: once full, the oldest result is dropped to make room. When
+// created with a path it is also written to disk, encrypted, after every
+// change so results survive a beacon restart.
+type Outbox struct {
+	limit   int
+	path    string
+	aead    cipher.AEAD
+	results []rbhttp.HttpBody
+	dropped int
+	sync.Mutex
+}
+
+func New(limit int) (*Outbox, error) {
+	if limit < 1 {
+		return nil, fmt.Errorf("outbox size must be at least 1, got %d", limit)
+	}
+	return &Outbox{
+		limit:   limit,
+		results: make([]rbhttp.HttpBody, 0),
+	}, nil
+}
+
+// NewPersistent creates an outbox backed by an AES-GCM encrypted file at path,
+// loading any results left over from a previous run. The key may be any
+// passphrase; it is stretched to a 256-bit key with SHA-256.
+func NewPersistent(limit int, path, key string) (*Outbox, error) {
+	o, err := New(limit)
+	if err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return nil, errors.New("an encryption key is required for an on-disk outbox")
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	o.path = path
+	o.aead = aead
+	if err := o.load(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Push queues a result for delivery.
+func (o *Outbox) Push(result rbhttp.HttpBody) error {
+	o.Lock()
+	defer o.Unlock()
+
+	if len(o.results) >= o.limit {
+		drop := len(o.results) - o.limit + 1
+		o.results = append(o.results[:0], o.results[drop:]...)
+		o.dropped += drop
+	}
+	o.results = append(o.results, result)
+	return o.save()
+}
+
+// Flush sends queued results oldest first in batches of up to batchSize, or
+// all at once if batchSize is 0, removing each batch the send function
+// accepts. It stops at the first error
+// so results are retried in order on the next flush; batches for which
+// discard returns true are dropped instead, for errors that retrying cannot
+// fix.
+func (o *Outbox) Flush(batchSize int, send func([]rbhttp.HttpBody) error, discard func(error) bool) error {
+	o.Lock()
+	defer o.Unlock()
+
+	if batchSize < 1 {
+		batchSize = len(o.results)
+	}
+
+	var sendErr error
+	sent := 0
+	for sent < len(o.results) {
+		batch := o.results[sent:min(sent+batchSize, len(o.results))]
+		if err := send(batch); err != nil && !discard(err) {
+			sendErr = err
+			break
+		}
+		sent += len(batch)
+	}
+
+	if sent == 0 {
+		return sendErr
+	}
+	o.results = append(o.results[:0], o.results[sent:]...)
+	if err := o.save(); err != nil {
+		return err
+	}
+	return sendErr
+}
+
+func (o *Outbox) Len() int {
+	o.Lock()
+	defer o.Unlock()
+	return len(o.results)
+}
+
+// Dropped returns how many results have been discarded because the outbox
+// was full.
+func (o *Outbox) Dropped() int {
+	o.Lock()
+	defer o.Unlock()
+	return o.dropped
+}
+
+func (o *Outbox) load() error {
+	sealed, err := os.ReadFile(o.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read outbox: %w", err)
+	}
+
+	nonceSize := o.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return fmt.Errorf("outbox file '%s' is truncated", o.path)
+	}
+	plain, err := o.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt outbox: %w", err)
+	}
+
+	if err := json.Unmarshal(plain, &o.results); err != nil {
+		return fmt.Errorf("failed to decode outbox: %w", err)
+	}
+	if len(o.results) > o.limit {
+		o.results = o.results[len(o.results)-o.limit:]
+	}
+	return nil
+}
+
+// save writes the outbox to a temporary file and renames it into place, so a
+// crash mid-write never leaves a corrupt outbox behind.
+func (o *Outbox) save() error {
+	if o.path == "" {
+		return nil
+	}
+
+	plain, err := json.Marshal(o.results)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox: %w", err)
+	}
+	nonce := make([]byte, o.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := o.aead.Seal(nonce, nonce, plain, nil)
+
+	tmp, err := os.CreateTemp(filepath.Dir(o.path), ".outbox-*")
+	if err != nil {
+		return fmt.Errorf("failed to write outbox: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(sealed); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write outbox: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write outbox: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), o.path); err != nil {
+		return fmt.Errorf("failed to write outbox: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package rbparse
+
+import (
+	"encoding/json"
+	"fmt"
+	"redbull/internal/rbresult"
+	"regexp"
+	"strings"
+)
+
+// idField matches one field of id output, such as uid=0(root) or
+// groups=0(root),4(adm).
+var idField = regexp.MustCompile(`(\w+)=(\S+)`)
+
+// idEntry matches an id with an optional name, such as 0(root).
+var idEntry = regexp.MustCompile(`^(\d+)(?:\((.*)\))?$`)
+
+// privilegedGroups are groups that usually amount to root.
+var privilegedGroups = map[string]bool{"root": true, "wheel": true, "sudo": true, "admin": true, "docker": true, "lxd": true, "disk": true}
+
+type IdParser struct{}
+
+func (p *IdParser) Parse(cmd Command, stdout string) (Result, error) {
+	var id rbresult.Identity
+	switch {
+	case cmd.JSON:
+		if err := json.Unmarshal([]byte(stdout), &id); err != nil || id.User == "" && id.UID == "" {
+			return Result{}, ErrUnrecognised
+		}
+	case strings.Contains(stdout, "uid="):
+		id = parseId(stdout)
+		if id.UID == "" {
+			return Result{}, ErrUnrecognised
+		}
+	default:
+		// whoami prints just the name, DOMAIN\name on Windows
+		fields := strings.Fields(stdout)
+		if len(fields) != 1 {
+			return Result{}, ErrUnrecognised
+		}
+		id.User = fields[0]
+	}
+	return identityResult(id), nil
+}
+
+func parseId(stdout string) rbresult.Identity {
+	var id rbresult.Identity
+	for _, m := range idField.FindAllStringSubmatch(stdout, -1) {
+		key, value := m[1], m[2]
+		switch key {
+		case "uid", "gid", "euid", "egid":
+			entry := idEntry.FindStringSubmatch(value)
+			if entry == nil {
+				continue
+			}
+			switch key {
+			case "uid":
+				id.UID, id.User = entry[1], entry[2]
+			case "gid":
+				id.GID = entry[1]
+			case "euid":
+				id.EUID = entry[1]
+			case "egid":
+				id.EGID = entry[1]
+			}
+		case "groups":
+			for _, group := range strings.Split(value, ",") {
+				if entry := idEntry.FindStringSubmatch(group); entry != nil {
+					id.Groups = append(id.Groups, rbresult.Group{ID: entry[1], Name: entry[2]})
+				}
+			}
+		case "capabilities":
+			id.Capabilities = strings.Split(value, ",")
+		}
+	}
+	return id
+}
+
+func identityResult(id rbresult.Identity) Result {
+	groups := make([]string, 0, len(id.Groups))
+	privileged := make([]string, 0)
+	for _, group := range id.Groups {
+		name := group.Name
+		if name == "" {
+			name = group.ID
+		}
+		groups = append(groups, name)
+		if privilegedGroups[group.Name] && group.Name != "root" {
+			privileged = append(privileged, group.Name)
+		}
+	}
+
+	table := &Table{Columns: []string{"FIELD", "VALUE"}, Rows: [][]string{{"user", id.User}}}
+	for _, field := range [][2]string{{"uid", id.UID}, {"gid", id.GID}, {"euid", id.EUID}, {"egid", id.EGID}} {
+		if field[1] != "" {
+			table.Rows = append(table.Rows, []string{field[0], field[1]})
+		}
+	}
+	if len(groups) > 0 {
+		table.Rows = append(table.Rows, []string{"groups", strings.Join(groups, ", ")})
+	}
+	if len(id.Capabilities) > 0 {
+		table.Rows = append(table.Rows, []string{"capabilities", strings.Join(id.Capabilities, ", ")})
+	}
+
+	summary := id.User
+	if id.UID != "" {
+		summary = fmt.Sprintf("%s (uid %s)", id.User, id.UID)
+	}
+	switch {
+	case id.UID == "0" || id.EUID == "0":
+		summary += ", root"
+	case len(privileged) > 0:
+		summary += ", in privileged groups: " + strings.Join(privileged, ", ")
+	}
+	if len(id.Capabilities) > 0 {
+		summary += fmt.Sprintf(", %d capabilities", len(id.Capabilities))
+	}
+	return Result{Kind: KindIdentity, Summary: summary, Table: table, Data: id}
+}
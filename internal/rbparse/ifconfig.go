@@ -0,0 +1,182 @@
+package rbparse
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"redbull/internal/rbresult"
+	"strconv"
+	"strings"
+)
+
+type IfconfigParser struct{}
+
+func (p *IfconfigParser) Parse(cmd Command, stdout string) (Result, error) {
+	var interfaces []rbresult.Interface
+	if cmd.JSON {
+		if err := json.Unmarshal([]byte(stdout), &interfaces); err != nil {
+			return Result{}, ErrUnrecognised
+		}
+	} else {
+		interfaces = parseIfconfig(stdout)
+		if len(interfaces) == 0 {
+			return Result{}, ErrUnrecognised
+		}
+	}
+	return interfacesResult(interfaces), nil
+}
+
+// parseIfconfig reads the blocks ifconfig prints for each interface: the
+// beacon's own, and those of Linux (both net-tools styles), macOS and BSD.
+// A block starts with an unindented line naming the interface.
+func parseIfconfig(stdout string) []rbresult.Interface {
+	interfaces := make([]rbresult.Interface, 0)
+	var current *rbresult.Interface
+	for _, line := range lines(stdout) {
+		fields := strings.Fields(line)
+		if line[0] != ' ' && line[0] != '\t' {
+			interfaces = append(interfaces, rbresult.Interface{Name: strings.TrimSuffix(fields[0], ":"), Addresses: make([]string, 0)})
+			current = &interfaces[len(interfaces)-1]
+			fields = fields[1:]
+		}
+		if current == nil {
+			continue
+		}
+		parseIfconfigFields(current, fields)
+	}
+
+	// Anything without a single detail was not an interface block
+	parsed := make([]rbresult.Interface, 0, len(interfaces))
+	for _, iface := range interfaces {
+		if iface.MTU > 0 || iface.MAC != "" || len(iface.Addresses) > 0 {
+			parsed = append(parsed, iface)
+		}
+	}
+	return parsed
+}
+
+func parseIfconfigFields(iface *rbresult.Interface, fields []string) {
+	next := func(i int) string {
+		if i+1 < len(fields) {
+			return fields[i+1]
+		}
+		return ""
+	}
+
+	for i, field := range fields {
+		switch {
+		case field == "inet" || field == "inet6":
+			addr := next(i)
+			if addr == "addr:" {
+				addr = next(i + 1)
+			}
+			addr = strings.TrimPrefix(addr, "addr:")
+			if addr == "" {
+				continue
+			}
+			iface.Addresses = append(iface.Addresses, withMask(addr, fields[i+1:]))
+		case field == "ether" || field == "HWaddr" || field == "lladdr":
+			iface.MAC = strings.ToLower(next(i))
+		case field == "mtu":
+			iface.MTU, _ = strconv.Atoi(next(i))
+		case strings.HasPrefix(field, "MTU:"):
+			iface.MTU, _ = strconv.Atoi(strings.TrimPrefix(field, "MTU:"))
+		case field == "index":
+			iface.Index, _ = strconv.Atoi(next(i))
+		case strings.Contains(field, "<") && strings.HasSuffix(field, ">"):
+			// flags=4163<UP,BROADCAST,RUNNING> or the beacon's <up,broadcast>
+			flags := field[strings.Index(field, "<")+1 : len(field)-1]
+			if flags != "" {
+				iface.Flags = strings.Split(strings.ToLower(flags), ",")
+			}
+		}
+	}
+
+	// Older net-tools put the flags in capitals on the line with MTU:
+	if len(fields) > 0 && strings.HasPrefix(fields[len(fields)-1], "Metric:") {
+		for _, field := range fields {
+			if strings.Contains(field, ":") {
+				break
+			}
+			iface.Flags = append(iface.Flags, strings.ToLower(field))
+		}
+	}
+}
+
+// withMask adds the prefix length from whichever of "netmask 255.255.255.0",
+// "netmask 0xffffff00", "prefixlen 64" or "Mask:255.255.255.0" follows the
+// address, unless it is already in CIDR notation.
+func withMask(addr string, fields []string) string {
+	// Drop the zone from link-local IPv6 addresses
+	if i := strings.Index(addr, "%"); i >= 0 {
+		addr = addr[:i]
+	}
+	if strings.Contains(addr, "/") {
+		return addr
+	}
+
+	for i, field := range fields {
+		value := ""
+		switch {
+		case (field == "netmask" || field == "prefixlen") && i+1 < len(fields):
+			value = fields[i+1]
+		case strings.HasPrefix(field, "Mask:"):
+			value = strings.TrimPrefix(field, "Mask:")
+		default:
+			continue
+		}
+		if bits, ok := maskBits(value); ok {
+			return addr + "/" + strconv.Itoa(bits)
+		}
+		break
+	}
+	return addr
+}
+
+func maskBits(mask string) (int, bool) {
+	if bits, err := strconv.Atoi(mask); err == nil {
+		return bits, true
+	}
+	if hex, ok := strings.CutPrefix(mask, "0x"); ok {
+		n, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return 0, false
+		}
+		ip := net.IPv4(byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+		mask = ip.String()
+	}
+	ip := net.ParseIP(mask).To4()
+	if ip == nil {
+		return 0, false
+	}
+	bits, total := net.IPMask(ip).Size()
+	return bits, total > 0
+}
+
+func interfacesResult(interfaces []rbresult.Interface) Result {
+	table := &Table{Columns: []string{"NAME", "MTU", "MAC", "FLAGS", "ADDRESSES"}, Rows: make([][]string, 0, len(interfaces))}
+	addresses := make([]string, 0)
+	for _, iface := range interfaces {
+		mtu := ""
+		if iface.MTU > 0 {
+			mtu = strconv.Itoa(iface.MTU)
+		}
+		table.Rows = append(table.Rows, []string{iface.Name, mtu, iface.MAC, strings.Join(iface.Flags, ","), strings.Join(iface.Addresses, ", ")})
+
+		for _, addr := range iface.Addresses {
+			ip, _, err := net.ParseCIDR(addr)
+			if err != nil {
+				ip = net.ParseIP(addr)
+			}
+			if ip != nil && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() {
+				addresses = append(addresses, iface.Name+" "+addr)
+			}
+		}
+	}
+
+	summary := fmt.Sprintf("%d interfaces", len(interfaces))
+	if len(addresses) > 0 {
+		summary += ": " + strings.Join(addresses, ", ")
+	}
+	return Result{Kind: KindInterfaces, Summary: summary, Table: table, Data: interfaces}
+}
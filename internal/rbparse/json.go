@@ -0,0 +1,118 @@
+package rbparse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// parseJSON tables any JSON a native command printed: an object as field and
+// value rows, or an array of objects with a column for each field.
+func parseJSON(stdout string) (Result, error) {
+	var data any
+	decoder := json.NewDecoder(strings.NewReader(stdout))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return Result{}, ErrUnrecognised
+	}
+
+	result := Result{Kind: KindJSON, Data: data}
+	switch value := data.(type) {
+	case map[string]any:
+		keys, _ := objectKeys([]byte(stdout))
+		result.Table = &Table{Columns: []string{"FIELD", "VALUE"}, Rows: make([][]string, 0, len(keys))}
+		for _, key := range keys {
+			result.Table.Rows = append(result.Table.Rows, []string{key, formatValue(value[key])})
+		}
+		result.Summary = fmt.Sprintf("%d fields", len(keys))
+	case []any:
+		result.Summary = fmt.Sprintf("%d items", len(value))
+		result.Table = arrayTable(stdout, value)
+	default:
+		result.Summary = formatValue(value)
+	}
+	return result, nil
+}
+
+// arrayTable has a column for every field of the array's objects, in the
+// order they first appear, or returns nil if it holds anything else.
+func arrayTable(stdout string, values []any) *Table {
+	var raw []json.RawMessage
+	if err := json.Unmarshal([]byte(stdout), &raw); err != nil {
+		return nil
+	}
+
+	columns := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, item := range raw {
+		keys, ok := objectKeys(item)
+		if !ok {
+			return nil
+		}
+		for _, key := range keys {
+			if !seen[key] {
+				seen[key] = true
+				columns = append(columns, key)
+			}
+		}
+	}
+
+	if len(columns) == 0 {
+		return nil
+	}
+	table := &Table{Columns: columns, Rows: make([][]string, 0, len(values))}
+	for _, value := range values {
+		object := value.(map[string]any)
+		row := make([]string, 0, len(columns))
+		for _, column := range columns {
+			row = append(row, formatValue(object[column]))
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// objectKeys lists the keys of a JSON object in order, which decoding into a
+// map loses.
+func objectKeys(data []byte) ([]string, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, false
+	}
+
+	keys := make([]string, 0)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, false
+		}
+		keys = append(keys, token.(string))
+		var skip json.RawMessage
+		if err := decoder.Decode(&skip); err != nil {
+			return nil, false
+		}
+	}
+	return keys, true
+}
+
+// formatValue renders a decoded JSON value for a table cell, nesting as
+// compact JSON.
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprint(v)
+	default:
+		out, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(out)
+	}
+}
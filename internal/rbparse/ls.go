@@ -0,0 +1,155 @@
+package rbparse
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"redbull/internal/rbresult"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lsLine matches an ls -l line: mode, an optional link count, owner, group,
+// size (or device numbers), a timestamp and the name. Timestamps are either
+// ISO, as the beacon and ls --time-style=long-iso print them, or the
+// traditional "Jan  2 15:04" and "Jan  2  2006".
+var lsLine = regexp.MustCompile(`^\s*(\S*[-r][-w][-xsS][-r][-w][-xsS][-r][-w][-xtT][.+@]?)\s+(?:\d+\s+)?(\S+)\s+(\S+)\s+(\d+|\d+,\s*\d+)\s+` +
+	`(\d{4}-\d{2}-\d{2} \d{2}:\d{2}(?::\d{2}(?:\.\d+)?)?(?: [-+]\d{4})?|[A-Z][a-z]{2}\s+\d{1,2}\s+(?:\d{1,2}:\d{2}|\d{4}))\s(.+)$`)
+
+type LsParser struct{}
+
+func (p *LsParser) Parse(cmd Command, stdout string) (Result, error) {
+	var listing rbresult.Listing
+	if cmd.JSON {
+		if err := json.Unmarshal([]byte(stdout), &listing); err != nil {
+			return Result{}, ErrUnrecognised
+		}
+	} else {
+		listing.Entries = parseLsLong(stdout)
+		if len(listing.Entries) == 0 {
+			return Result{}, ErrUnrecognised
+		}
+	}
+	return filesResult(listing), nil
+}
+
+// parseLsLong reads ls -l output, including the directory headings of ls -R.
+func parseLsLong(stdout string) []rbresult.File {
+	files := make([]rbresult.File, 0)
+	dir := ""
+	for _, line := range lines(stdout) {
+		if strings.HasPrefix(line, "total ") {
+			continue
+		}
+		m := lsLine.FindStringSubmatch(line)
+		if m == nil {
+			if heading, ok := strings.CutSuffix(line, ":"); ok && !strings.HasPrefix(line, " ") {
+				dir = heading
+			}
+			continue
+		}
+
+		file := rbresult.File{Mode: m[1], Type: modeType(m[1]), Owner: dashEmpty(m[2]), Group: dashEmpty(m[3]), Name: m[6]}
+		if size, err := strconv.ParseInt(m[4], 10, 64); err == nil {
+			file.Size = size
+		}
+		file.ModTime = parseLsTime(m[5])
+		if name, target, ok := strings.Cut(file.Name, " -> "); ok && file.Type == "symlink" {
+			file.Name, file.LinkTarget = name, target
+		}
+		if dir != "" {
+			file.Path = path.Join(dir, file.Name)
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// modeType reads the file type from the first letter of a mode, in either
+// ls's notation or Go's, which the beacon prints.
+func modeType(mode string) string {
+	switch mode[0] {
+	case 'd':
+		return "dir"
+	case 'l', 'L':
+		return "symlink"
+	case '-':
+		return "file"
+	default:
+		return "other"
+	}
+}
+
+func parseLsTime(s string) time.Time {
+	s = strings.Join(strings.Fields(s), " ")
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02 15:04:05", "2006-01-02 15:04:05.999999999 -0700", "Jan 2 2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	// Recent files show a time instead of the year
+	if t, err := time.Parse("Jan 2 15:04", s); err == nil {
+		now := time.Now()
+		t = t.AddDate(now.Year(), 0, 0)
+		if t.After(now.AddDate(0, 1, 0)) {
+			t = t.AddDate(-1, 0, 0)
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func filesResult(listing rbresult.Listing) Result {
+	table := &Table{Columns: []string{"MODE", "OWNER", "GROUP", "SIZE", "MODIFIED", "NAME"}, Rows: make([][]string, 0, len(listing.Entries))}
+	dirs, suid := 0, make([]string, 0)
+	for _, file := range listing.Entries {
+		modified := ""
+		if !file.ModTime.IsZero() {
+			modified = file.ModTime.Format("2006-01-02 15:04")
+		}
+		name := file.Name
+		if file.LinkTarget != "" {
+			name += " -> " + file.LinkTarget
+		}
+		table.Rows = append(table.Rows, []string{file.Mode, file.Owner, file.Group, strconv.FormatInt(file.Size, 10), modified, name})
+
+		if file.Type == "dir" {
+			dirs++
+		}
+		if isSetuid(file.Mode) && file.Type != "dir" {
+			suid = append(suid, file.Name)
+		}
+	}
+
+	summary := fmt.Sprintf("%d entries (%d directories)", len(listing.Entries), dirs)
+	if listing.Path != "" {
+		summary += " in " + listing.Path
+	}
+	if listing.Truncated {
+		summary += ", truncated"
+	}
+	if len(suid) > 0 {
+		summary += "; setuid/setgid: " + strings.Join(suid, ", ")
+	}
+	return Result{Kind: KindFiles, Summary: summary, Table: table, Data: listing}
+}
+
+// isSetuid spots the setuid and setgid bits in either notation.
+func isSetuid(mode string) bool {
+	mode = strings.TrimRight(mode, ".+@")
+	if len(mode) < 9 {
+		return false
+	}
+	perms := mode[len(mode)-9:]
+	prefix := mode[:len(mode)-9]
+	return strings.ContainsAny(perms[2:3]+perms[5:6], "sS") || strings.ContainsAny(prefix, "ug")
+}
+
+func dashEmpty(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
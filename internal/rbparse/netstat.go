@@ -0,0 +1,221 @@
+package rbparse
+
+import (
+	"encoding/json"
+	"fmt"
+	"redbull/internal/rbresult"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type NetstatParser struct{}
+
+func (p *NetstatParser) Parse(cmd Command, stdout string) (Result, error) {
+	if cmd.JSON {
+		if cmd.HasFlag("-r") {
+			var routes []rbresult.Route
+			if err := json.Unmarshal([]byte(stdout), &routes); err != nil {
+				return Result{}, ErrUnrecognised
+			}
+			return routesResult(routes), nil
+		}
+		var sockets []rbresult.Socket
+		if err := json.Unmarshal([]byte(stdout), &sockets); err != nil {
+			return Result{}, ErrUnrecognised
+		}
+		return socketsResult(sockets), nil
+	}
+
+	if routes := parseRoutes(stdout); len(routes) > 0 {
+		return routesResult(routes), nil
+	}
+	if sockets := parseSockets(stdout, cmd.Native); len(sockets) > 0 {
+		return socketsResult(sockets), nil
+	}
+	return Result{}, ErrUnrecognised
+}
+
+// parseSockets reads the TCP and UDP rows of netstat output, skipping
+// headings and Unix sockets. Rows are "proto [recv-q send-q] local remote
+// [state] [pid/program]", the queues missing on Windows. The beacon always
+// prints every column, with "-" for those it has nothing for.
+func parseSockets(stdout string, native bool) []rbresult.Socket {
+	sockets := make([]rbresult.Socket, 0)
+	for _, line := range lines(stdout) {
+		fields := strings.Fields(line)
+		proto := strings.ToLower(cell(fields, 0))
+		if !strings.HasPrefix(proto, "tcp") && !strings.HasPrefix(proto, "udp") {
+			continue
+		}
+		if native {
+			if len(fields) != 6 {
+				continue
+			}
+			socket := rbresult.Socket{Proto: proto, Local: fields[1], Remote: fields[2], State: cell(fields, 3), User: cell(fields, 4)}
+			if pid, program, ok := strings.Cut(fields[5], "/"); ok {
+				socket.PID, _ = strconv.Atoi(pid)
+				socket.Process = program
+			}
+			sockets = append(sockets, socket)
+			continue
+		}
+		fields = fields[1:]
+		if len(fields) >= 4 && isNumber(fields[0]) && isNumber(fields[1]) {
+			fields = fields[2:]
+		}
+		if len(fields) < 2 {
+			continue
+		}
+
+		socket := rbresult.Socket{Proto: proto, Local: fields[0], Remote: fields[1]}
+		rest := fields[2:]
+		if len(rest) > 0 && !isProcess(rest[0]) && !isNumber(rest[0]) {
+			socket.State = rest[0]
+			rest = rest[1:]
+		}
+		if len(rest) > 0 {
+			pid, program, _ := strings.Cut(rest[0], "/")
+			socket.PID, _ = strconv.Atoi(pid)
+			socket.Process = strings.TrimSpace(strings.Join(append([]string{program}, rest[1:]...), " "))
+		}
+		sockets = append(sockets, socket)
+	}
+	return sockets
+}
+
+// isProcess matches the pid/program column, or its "-" placeholder.
+func isProcess(s string) bool {
+	if s == "-" {
+		return true
+	}
+	pid, _, ok := strings.Cut(s, "/")
+	return ok && isNumber(pid)
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+// parseRoutes reads a routing table with a Destination heading, as printed
+// by netstat -r on Linux, macOS and BSD, and by the beacon.
+func parseRoutes(stdout string) []rbresult.Route {
+	rows := lines(stdout)
+	start := -1
+	for i, line := range rows {
+		if fields := strings.Fields(line); len(fields) > 0 && strings.EqualFold(fields[0], "Destination") {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil
+	}
+
+	header := strings.Fields(rows[start])
+	gateway := column(header, "Gateway")
+	mask := column(header, "Genmask", "Netmask")
+	iface := column(header, "Iface", "Interface", "Netif")
+	metric := column(header, "Metric")
+
+	routes := make([]rbresult.Route, 0)
+	for _, line := range rows[start+1:] {
+		row := strings.Fields(line)
+		// macOS prints a table per address family, each with its own heading
+		if len(row) < 2 || strings.EqualFold(row[0], "Destination") || strings.HasSuffix(line, ":") {
+			continue
+		}
+		route := rbresult.Route{Destination: row[0], Gateway: cell(row, gateway), Mask: cell(row, mask), Interface: cell(row, iface)}
+		route.Metric, _ = strconv.Atoi(cell(row, metric))
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+func socketsResult(sockets []rbresult.Socket) Result {
+	table := &Table{Columns: []string{"PROTO", "LOCAL", "REMOTE", "STATE", "USER", "PROCESS"}, Rows: make([][]string, 0, len(sockets))}
+	listening := make(map[string]bool)
+	established := 0
+	for _, socket := range sockets {
+		process := socket.Process
+		if socket.PID > 0 {
+			process = strings.TrimSuffix(strconv.Itoa(socket.PID)+"/"+socket.Process, "/")
+		}
+		table.Rows = append(table.Rows, []string{socket.Proto, socket.Local, socket.Remote, socket.State, socket.User, process})
+
+		switch {
+		case isListening(socket):
+			listening[port(socket.Local)+"/"+strings.TrimRight(socket.Proto, "46")] = true
+		case strings.EqualFold(socket.State, "ESTABLISHED"):
+			established++
+		}
+	}
+
+	ports := make([]string, 0, len(listening))
+	for p := range listening {
+		ports = append(ports, p)
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		pi, _ := strconv.Atoi(strings.Split(ports[i], "/")[0])
+		pj, _ := strconv.Atoi(strings.Split(ports[j], "/")[0])
+		if pi != pj {
+			return pi < pj
+		}
+		return ports[i] < ports[j]
+	})
+
+	summary := fmt.Sprintf("%d sockets, %d established", len(sockets), established)
+	if len(ports) > 0 {
+		summary += ", listening on " + strings.Join(ports, ", ")
+	}
+	return Result{Kind: KindSockets, Summary: summary, Table: table, Data: sockets}
+}
+
+// isListening counts UDP sockets with no remote end as listening.
+func isListening(socket rbresult.Socket) bool {
+	state := strings.ToUpper(socket.State)
+	if state == "LISTEN" || state == "LISTENING" {
+		return true
+	}
+	if strings.HasPrefix(socket.Proto, "udp") && state == "" {
+		return true
+	}
+	return false
+}
+
+// port takes the port from addresses like 0.0.0.0:22, [::]:22, :::22 and
+// macOS's *.22.
+func port(addr string) string {
+	if i := strings.LastIndexAny(addr, ":."); i >= 0 {
+		return addr[i+1:]
+	}
+	return addr
+}
+
+func routesResult(routes []rbresult.Route) Result {
+	table := &Table{Columns: []string{"DESTINATION", "GATEWAY", "MASK", "INTERFACE", "METRIC"}, Rows: make([][]string, 0, len(routes))}
+	defaults := make([]string, 0)
+	for _, route := range routes {
+		metric := ""
+		if route.Metric > 0 {
+			metric = strconv.Itoa(route.Metric)
+		}
+		table.Rows = append(table.Rows, []string{route.Destination, route.Gateway, route.Mask, route.Interface, metric})
+
+		switch route.Destination {
+		case "default", "0.0.0.0", "0.0.0.0/0", "::/0":
+			if route.Gateway == "" {
+				defaults = append(defaults, route.Interface)
+			} else {
+				defaults = append(defaults, fmt.Sprintf("%s via %s", route.Interface, route.Gateway))
+			}
+		}
+	}
+
+	summary := fmt.Sprintf("%d routes", len(routes))
+	if len(defaults) > 0 {
+		summary += ", default: " + strings.Join(defaults, ", ")
+	}
+	return Result{Kind: KindRoutes, Summary: summary, Table: table, Data: routes}
+}
@@ -0,0 +1,199 @@
+package rbparse
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	KindFiles      = "files"
+	KindProcesses  = "processes"
+	KindIdentity   = "identity"
+	KindInterfaces = "interfaces"
+	KindSockets    = "sockets"
+	KindRoutes     = "routes"
+	KindJSON       = "json"
+)
+
+var ErrUnrecognised = errors.New("output not recognised")
+
+// Result is structured data parsed from a command's output. Table is the
+// same data flattened for display, and Summary a line or two for reports.
+type Result struct {
+	Parser  string `json:"parser"`
+	Kind    string `json:"kind"`
+	Summary string `json:"summary"`
+	Table   *Table `json:"table,omitempty"`
+	Data    any    `json:"data"`
+}
+
+type Table struct {
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+}
+
+// Command is a beacon command line as far as parsers care about it.
+type Command struct {
+	// Name is the beacon command, e.g. "shell" or "ps"
+	Name string
+	// Program is what produced the output: the beacon command itself, or the
+	// program a shell command ran
+	Program string
+	// Args follow Program
+	Args []string
+	// Native is false for output of a program run by a shell
+	Native bool
+	// JSON is set for native commands run with -json
+	JSON bool
+}
+
+// Parser turns the output of one program into structured data, returning
+// ErrUnrecognised if it does not look like that program's output.
+type Parser interface {
+	Parse(cmd Command, stdout string) (Result, error)
+}
+
+// registry maps program names to the parser for their output.
+var registry = map[string]Parser{
+	"ls":       &LsParser{},
+	"ps":       &PsParser{},
+	"id":       &IdParser{},
+	"whoami":   &IdParser{},
+	"ifconfig": &IfconfigParser{},
+	"netstat":  &NetstatParser{},
+}
+
+// shells run the rest of the command line as a program.
+var shells = map[string]bool{"shell": true, "shellsession": true}
+
+// ParseCommand splits a beacon command line into what parsers need to know.
+func ParseCommand(line string) Command {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return Command{}
+	}
+
+	cmd := Command{Name: fields[0], Program: fields[0], Args: fields[1:], Native: true}
+	if shells[cmd.Name] {
+		cmd.Native = false
+		args := fields[1:]
+		// Look past sudo and variable assignments to the program itself
+		for len(args) > 0 && (args[0] == "sudo" || strings.Contains(args[0], "=")) {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			return Command{Name: cmd.Name}
+		}
+		cmd.Program = baseName(args[0])
+		cmd.Args = args[1:]
+		return cmd
+	}
+
+	for _, arg := range cmd.Args {
+		if arg == "--" {
+			break
+		}
+		if arg == "-json" || arg == "--json" {
+			cmd.JSON = true
+		}
+	}
+	return cmd
+}
+
+// Parse returns structured data for the output of command, or nil if no
+// parser recognises it.
+func Parse(command, stdout string) *Result {
+	if strings.TrimSpace(stdout) == "" {
+		return nil
+	}
+	cmd := ParseCommand(command)
+
+	if parser, ok := registry[cmd.Program]; ok {
+		if result, err := parser.Parse(cmd, stdout); err == nil {
+			result.Parser = cmd.Program
+			return &result
+		}
+	}
+	// Any other native command's JSON is still worth a table
+	if cmd.Native && cmd.JSON {
+		if result, err := parseJSON(stdout); err == nil {
+			result.Parser = KindJSON
+			return &result
+		}
+	}
+	return nil
+}
+
+// HasFlag reports whether cmd was given any of the flags.
+func (cmd Command) HasFlag(flags ...string) bool {
+	for _, arg := range cmd.Args {
+		if arg == "--" {
+			return false
+		}
+		for _, flag := range flags {
+			if arg == flag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func baseName(path string) string {
+	if i := strings.LastIndexAny(path, `/\`); i >= 0 {
+		path = path[i+1:]
+	}
+	return strings.TrimSuffix(strings.ToLower(path), ".exe")
+}
+
+// lines splits output into its non-blank lines.
+func lines(stdout string) []string {
+	all := strings.Split(strings.ReplaceAll(stdout, "\r\n", "\n"), "\n")
+	result := make([]string, 0, len(all))
+	for _, line := range all {
+		if strings.TrimSpace(line) != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// splitFields splits line on whitespace into at most n fields, the last
+// keeping the rest of the line as it was, spaces and all.
+func splitFields(line string, n int) []string {
+	fields := make([]string, 0, n)
+	rest := strings.TrimSpace(line)
+	for len(fields) < n-1 && rest != "" {
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			break
+		}
+		fields = append(fields, rest[:end])
+		rest = strings.TrimLeft(rest[end:], " \t")
+	}
+	if rest != "" {
+		fields = append(fields, rest)
+	}
+	return fields
+}
+
+// column finds a table column by any of its names, case-insensitively,
+// returning -1 if there is none.
+func column(header []string, names ...string) int {
+	for i, h := range header {
+		for _, name := range names {
+			if strings.EqualFold(h, name) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// cell returns row[i], or "" for a missing column or a "-" placeholder.
+func cell(row []string, i int) string {
+	if i < 0 || i >= len(row) || row[i] == "-" {
+		return ""
+	}
+	return row[i]
+}
@@ -0,0 +1,118 @@
+package rbparse
+
+import (
+	"encoding/json"
+	"fmt"
+	"redbull/internal/rbresult"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type PsParser struct{}
+
+func (p *PsParser) Parse(cmd Command, stdout string) (Result, error) {
+	var processes []rbresult.Process
+	if cmd.JSON {
+		if err := json.Unmarshal([]byte(stdout), &processes); err != nil {
+			return Result{}, ErrUnrecognised
+		}
+	} else {
+		processes = parsePs(stdout, cmd.Native)
+		if len(processes) == 0 {
+			return Result{}, ErrUnrecognised
+		}
+	}
+	return processesResult(processes), nil
+}
+
+// parsePs reads a ps table with a header row, splitting rows on whitespace.
+// The beacon's start times contain a space, so their two fields are joined.
+func parsePs(stdout string, native bool) []rbresult.Process {
+	rows := lines(stdout)
+	if len(rows) < 2 {
+		return nil
+	}
+	header := strings.Fields(rows[0])
+	pid := column(header, "PID")
+	if pid < 0 {
+		return nil
+	}
+	ppid := column(header, "PPID")
+	user := column(header, "USER", "UID", "UNAME")
+	state := column(header, "STATE", "STAT", "S")
+	start := column(header, "STARTED", "START", "STIME", "LSTART")
+	cpu := column(header, "%CPU", "C")
+	mem := column(header, "%MEM")
+	command := column(header, "COMMAND", "CMD", "ARGS", "COMM")
+
+	processes := make([]rbresult.Process, 0, len(rows)-1)
+	for _, line := range rows[1:] {
+		var row []string
+		if native && start >= 0 {
+			row = splitFields(line, len(header)+1)
+			if len(row) > start+1 {
+				row = append(row[:start], append([]string{row[start] + " " + row[start+1]}, row[start+2:]...)...)
+			}
+		} else {
+			row = splitFields(line, len(header))
+		}
+		n, err := strconv.Atoi(cell(row, pid))
+		if err != nil {
+			continue
+		}
+		process := rbresult.Process{PID: n, User: cell(row, user), State: cell(row, state), Started: cell(row, start), Command: cell(row, command)}
+		process.PPID, _ = strconv.Atoi(cell(row, ppid))
+		process.CPU, _ = strconv.ParseFloat(cell(row, cpu), 64)
+		process.Memory, _ = strconv.ParseFloat(cell(row, mem), 64)
+		if fields := strings.Fields(process.Command); len(fields) > 0 {
+			process.Name = baseName(strings.Trim(fields[0], "[]"))
+		}
+		processes = append(processes, process)
+	}
+	return processes
+}
+
+// started shows when a process started: the time the beacon gave, or the
+// text another ps printed.
+func started(process rbresult.Process) string {
+	if process.StartTime.IsZero() {
+		return process.Started
+	}
+	return process.StartTime.Format("2006-01-02 15:04:05")
+}
+
+func processesResult(processes []rbresult.Process) Result {
+	table := &Table{Columns: []string{"PID", "PPID", "USER", "STATE", "STARTED", "COMMAND"}, Rows: make([][]string, 0, len(processes))}
+	users := make(map[string]int)
+	for _, process := range processes {
+		ppid := ""
+		if process.PPID > 0 {
+			ppid = strconv.Itoa(process.PPID)
+		}
+		table.Rows = append(table.Rows, []string{strconv.Itoa(process.PID), ppid, process.User, process.State, started(process), process.Command})
+		if process.User != "" {
+			users[process.User]++
+		}
+	}
+
+	summary := fmt.Sprintf("%d processes", len(processes))
+	if len(users) > 0 {
+		names := make([]string, 0, len(users))
+		for name := range users {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if users[names[i]] != users[names[j]] {
+				return users[names[i]] > users[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		counts := make([]string, 0, len(names))
+		for _, name := range names {
+			counts = append(counts, fmt.Sprintf("%s %d", name, users[name]))
+		}
+		summary += " by user: " + strings.Join(counts, ", ")
+	}
+	return Result{Kind: KindProcesses, Summary: summary, Table: table, Data: processes}
+}
@@ -0,0 +1,280 @@
+package rbpty
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"redbull/internal/rbhttp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxPending caps the output held for a terminal while the server is
+// unreachable; the oldest output is dropped beyond it.
+const maxPending = 1 << 20
+
+// defaultInterval is used when NewManager is given an interval that is not
+// positive.
+const defaultInterval = 100 * time.Millisecond
+
+// maxRetryInterval caps how far the wait between exchanges grows while the
+// server cannot be reached.
+const maxRetryInterval = 10 * time.Second
+
+var (
+	ErrPtyNotFound = errors.New("pty not found")
+	ErrPtyClosed   = errors.New("pty is closed")
+)
+
+// Exchanger carries terminal output to the server and input back. The
+// beacon's transport implements it.
+type Exchanger interface {
+	ExchangePty(sessionID string, output []rbhttp.PtyOutput) ([]rbhttp.PtyInput, error)
+}
+
+// Info describes a running terminal.
+type Info struct {
+	ID        string    `json:"id"`
+	PID       int       `json:"pid"`
+	Shell     string    `json:"shell"`
+	Cols      int       `json:"cols"`
+	Rows      int       `json:"rows"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+type terminal struct {
+	Info
+	pty      *os.File
+	cmd      *exec.Cmd
+	pending  []byte
+	closed   bool
+	exitCode int
+	sync.Mutex
+}
+
+// Manager runs the beacon's terminals. While any are open it exchanges
+// output and input with the server every interval, much more often than the
+// beacon checks in, so typing feels interactive.
+type Manager struct {
+	exchanger Exchanger
+	sessionID string
+	interval  time.Duration
+	terminals map[string]*terminal
+	pumping   bool
+	sync.Mutex
+}
+
+func NewManager(exchanger Exchanger, sessionID string, interval time.Duration) *Manager {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	return &Manager{
+		exchanger: exchanger,
+		sessionID: sessionID,
+		interval:  interval,
+		terminals: make(map[string]*terminal),
+	}
+}
+
+// Open starts shell under a new pty of the given size.
+func (m *Manager) Open(id, shell string, cols, rows int) (Info, error) {
+	m.Lock()
+	defer m.Unlock()
+	if _, ok := m.terminals[id]; ok {
+		return Info{}, errors.New("pty " + id + " is already open")
+	}
+
+	cmd := exec.Command(shell)
+	cmd.Env = append(os.Environ(), "TERM=xterm-256color")
+	f, err := start(cmd, cols, rows)
+	if err != nil {
+		return Info{}, err
+	}
+
+	t := &terminal{
+		Info: Info{ID: id, PID: cmd.Process.Pid, Shell: shell, Cols: cols, Rows: rows, StartedAt: time.Now()},
+		pty:  f,
+		cmd:  cmd,
+	}
+	m.terminals[id] = t
+	go t.read()
+	if !m.pumping {
+		m.pumping = true
+		go m.pump()
+	}
+	return t.Info, nil
+}
+
+// Close hangs up a terminal; its exit is reported on the next exchange.
+func (m *Manager) Close(id string) error {
+	m.Lock()
+	t, ok := m.terminals[id]
+	m.Unlock()
+	if !ok {
+		return ErrPtyNotFound
+	}
+	t.hangup()
+	return nil
+}
+
+// List describes the open terminals, oldest first.
+func (m *Manager) List() []Info {
+	m.Lock()
+	defer m.Unlock()
+
+	infos := make([]Info, 0, len(m.terminals))
+	for _, t := range m.terminals {
+		t.Lock()
+		infos = append(infos, t.Info)
+		t.Unlock()
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartedAt.Before(infos[j].StartedAt)
+	})
+	return infos
+}
+
+// hangup ends the shell the way closing a terminal window would. Closing
+// the pty alone does not wake a blocked read, so the shell is signalled too.
+func (t *terminal) hangup() {
+	hangup(t.cmd.Process)
+	t.pty.Close()
+}
+
+// read copies the terminal's output into pending until the shell exits.
+func (t *terminal) read() {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := t.pty.Read(buf)
+		if n > 0 {
+			t.Lock()
+			t.pending = append(t.pending, buf[:n]...)
+			if over := len(t.pending) - maxPending; over > 0 {
+				t.pending = t.pending[over:]
+			}
+			t.Unlock()
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	// Reading fails once the shell exits or the pty is closed under it
+	t.pty.Close()
+	t.cmd.Process.Kill()
+	err := t.cmd.Wait()
+	t.Lock()
+	defer t.Unlock()
+	t.closed = true
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		t.exitCode = exitErr.ExitCode()
+	}
+}
+
+// pump exchanges every interval, doubling the wait while exchanges fail so
+// an unreachable server is not hammered.
+func (m *Manager) pump() {
+	wait := m.interval
+	for {
+		time.Sleep(wait)
+		open, err := m.exchange()
+		if !open {
+			return
+		}
+		if err != nil {
+			wait = min(wait*2, max(maxRetryInterval, m.interval))
+		} else {
+			wait = m.interval
+		}
+	}
+}
+
+// exchange sends every terminal's pending output and applies the input that
+// comes back. It reports false once there are no terminals left to serve.
+func (m *Manager) exchange() (bool, error) {
+	m.Lock()
+	terminals := make([]*terminal, 0, len(m.terminals))
+	for _, t := range m.terminals {
+		terminals = append(terminals, t)
+	}
+	if len(terminals) == 0 {
+		m.pumping = false
+		m.Unlock()
+		return false, nil
+	}
+	m.Unlock()
+
+	output := make([]rbhttp.PtyOutput, 0, len(terminals))
+	sent := make([]int, len(terminals))
+	for i, t := range terminals {
+		t.Lock()
+		sent[i] = len(t.pending)
+		output = append(output, rbhttp.PtyOutput{
+			PtyID:    t.ID,
+			Data:     append([]byte(nil), t.pending...),
+			Closed:   t.closed,
+			ExitCode: t.exitCode,
+		})
+		t.Unlock()
+	}
+
+	input, err := m.exchanger.ExchangePty(m.sessionID, output)
+	if err != nil {
+		// Keep the output for the next attempt
+		return true, err
+	}
+
+	m.Lock()
+	for i, t := range terminals {
+		t.Lock()
+		t.pending = t.pending[min(sent[i], len(t.pending)):]
+		if output[i].Closed {
+			delete(m.terminals, t.ID)
+		}
+		t.Unlock()
+	}
+	m.Unlock()
+
+	for _, in := range input {
+		m.apply(in)
+	}
+	return true, nil
+}
+
+func (m *Manager) apply(in rbhttp.PtyInput) {
+	m.Lock()
+	t, ok := m.terminals[in.PtyID]
+	m.Unlock()
+	if !ok {
+		return
+	}
+
+	if len(in.Data) > 0 {
+		t.pty.Write(in.Data)
+	}
+	if in.Cols > 0 && in.Rows > 0 {
+		if err := resize(t.pty, in.Cols, in.Rows); err == nil {
+			t.Lock()
+			t.Cols, t.Rows = in.Cols, in.Rows
+			t.Unlock()
+		}
+	}
+	if in.Close {
+		t.hangup()
+	}
+}
+
+// DefaultShell is the user's login shell, or sh if it is unknown.
+func DefaultShell() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	for _, shell := range []string{"/bin/bash", "/bin/sh"} {
+		if _, err := os.Stat(shell); err == nil {
+			return shell
+		}
+	}
+	return "sh"
+}
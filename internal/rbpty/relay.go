@@ -0,0 +1,269 @@
+package rbpty
+
+import (
+	"redbull/internal/rbhttp"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	StateOpening = "opening"
+	StateOpen    = "open"
+	StateClosed  = "closed"
+)
+
+// maxBacklog is how much recent output the server keeps for each terminal,
+// so a client attaching late sees the current screen rather than nothing.
+const maxBacklog = 64 * 1024
+
+// subscriberBuffer is how many events an attached client can fall behind
+// before it is dropped rather than stalling the beacon's exchanges.
+const subscriberBuffer = 256
+
+// Terminal is the server's record of a beacon terminal.
+type Terminal struct {
+	ID        string    `json:"id"`
+	SessionID string    `json:"sessionId"`
+	TaskID    string    `json:"taskId"`
+	Shell     string    `json:"shell,omitempty"`
+	Cols      int       `json:"cols"`
+	Rows      int       `json:"rows"`
+	Operator  string    `json:"operator,omitempty"`
+	State     string    `json:"state"`
+	ExitCode  int       `json:"exitCode,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	ClosedAt  time.Time `json:"closedAt,omitzero"`
+}
+
+// Event is output for attached clients, or the terminal closing, in which
+// case the channel is closed after it.
+type Event struct {
+	Data     []byte
+	Closed   bool
+	ExitCode int
+	Error    string
+}
+
+type relayTerminal struct {
+	Terminal
+	input       []byte
+	cols, rows  int
+	close       bool
+	backlog     []byte
+	subscribers map[chan Event]struct{}
+}
+
+// Relay sits between a session's terminals on the beacon, which it hears
+// from through exchanges, and the clients attached to them.
+type Relay struct {
+	terminals map[string]*relayTerminal
+	sync.Mutex
+}
+
+func NewRelay() *Relay {
+	return &Relay{terminals: make(map[string]*relayTerminal)}
+}
+
+// Open records a terminal the beacon has been asked to start.
+func (r *Relay) Open(t Terminal) Terminal {
+	r.Lock()
+	defer r.Unlock()
+
+	t.State = StateOpening
+	t.CreatedAt = time.Now()
+	r.terminals[t.ID] = &relayTerminal{Terminal: t, subscribers: make(map[chan Event]struct{})}
+	return t
+}
+
+// Get returns a terminal by ID.
+func (r *Relay) Get(id string) (Terminal, bool) {
+	r.Lock()
+	defer r.Unlock()
+	t, ok := r.terminals[id]
+	if !ok {
+		return Terminal{}, false
+	}
+	return t.Terminal, true
+}
+
+// List returns the session's terminals, or every terminal for an empty
+// sessionID, newest first.
+func (r *Relay) List(sessionID string) []Terminal {
+	r.Lock()
+	defer r.Unlock()
+
+	terminals := make([]Terminal, 0)
+	for _, t := range r.terminals {
+		if sessionID == "" || t.SessionID == sessionID {
+			terminals = append(terminals, t.Terminal)
+		}
+	}
+	sort.Slice(terminals, func(i, j int) bool {
+		return terminals[i].CreatedAt.After(terminals[j].CreatedAt)
+	})
+	return terminals
+}
+
+// Exchange takes the output a beacon sends for its terminals and returns the
+// input waiting for them.
+func (r *Relay) Exchange(sessionID string, output []rbhttp.PtyOutput) []rbhttp.PtyInput {
+	r.Lock()
+	defer r.Unlock()
+
+	input := make([]rbhttp.PtyInput, 0)
+	for _, out := range output {
+		t, ok := r.terminals[out.PtyID]
+		if !ok || t.SessionID != sessionID || t.State == StateClosed {
+			// Nobody can attach to it any more, so have the beacon close it
+			if !out.Closed {
+				input = append(input, rbhttp.PtyInput{PtyID: out.PtyID, Close: true})
+			}
+			continue
+		}
+
+		if t.State == StateOpening {
+			t.State = StateOpen
+		}
+		if len(out.Data) > 0 {
+			t.backlog = append(t.backlog, out.Data...)
+			if over := len(t.backlog) - maxBacklog; over > 0 {
+				t.backlog = t.backlog[over:]
+			}
+			t.publish(Event{Data: out.Data})
+		}
+		if out.Closed {
+			t.ExitCode = out.ExitCode
+			t.finish("")
+			continue
+		}
+
+		if len(t.input) > 0 || t.cols > 0 || t.close {
+			input = append(input, rbhttp.PtyInput{PtyID: t.ID, Data: t.input, Cols: t.cols, Rows: t.rows, Close: t.close})
+			t.input, t.cols, t.rows = nil, 0, 0
+		}
+	}
+	return input
+}
+
+// OpenFailed closes a terminal whose open task failed on the beacon.
+func (r *Relay) OpenFailed(taskID, stderr string) {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, t := range r.terminals {
+		if t.TaskID == taskID && t.State == StateOpening {
+			t.finish(stderr)
+		}
+	}
+}
+
+// Prune forgets terminals that closed before the given time and returns how
+// many there were.
+func (r *Relay) Prune(before time.Time) int {
+	r.Lock()
+	defer r.Unlock()
+
+	pruned := 0
+	for id, t := range r.terminals {
+		if t.State == StateClosed && t.ClosedAt.Before(before) {
+			delete(r.terminals, id)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// Input queues keystrokes for the terminal.
+func (r *Relay) Input(id string, data []byte) error {
+	return r.update(id, func(t *relayTerminal) {
+		t.input = append(t.input, data...)
+	})
+}
+
+// Resize queues a new window size for the terminal.
+func (r *Relay) Resize(id string, cols, rows int) error {
+	return r.update(id, func(t *relayTerminal) {
+		t.cols, t.rows = cols, rows
+		t.Cols, t.Rows = cols, rows
+	})
+}
+
+// Close asks the beacon to hang up the terminal. One the beacon never
+// opened is closed straight away.
+func (r *Relay) Close(id string) error {
+	return r.update(id, func(t *relayTerminal) {
+		if t.State == StateOpening {
+			t.finish("closed before it opened")
+			return
+		}
+		t.close = true
+	})
+}
+
+func (r *Relay) update(id string, fn func(t *relayTerminal)) error {
+	r.Lock()
+	defer r.Unlock()
+
+	t, ok := r.terminals[id]
+	if !ok {
+		return ErrPtyNotFound
+	}
+	if t.State == StateClosed {
+		return ErrPtyClosed
+	}
+	fn(t)
+	return nil
+}
+
+// Attach subscribes to a terminal's output, returning what it has printed
+// recently. The channel is closed when the terminal closes or detach is
+// called.
+func (r *Relay) Attach(id string) (Terminal, []byte, <-chan Event, func(), error) {
+	r.Lock()
+	defer r.Unlock()
+
+	t, ok := r.terminals[id]
+	if !ok {
+		return Terminal{}, nil, nil, nil, ErrPtyNotFound
+	}
+	if t.State == StateClosed {
+		return t.Terminal, nil, nil, nil, ErrPtyClosed
+	}
+
+	events := make(chan Event, subscriberBuffer)
+	t.subscribers[events] = struct{}{}
+	detach := func() {
+		r.Lock()
+		defer r.Unlock()
+		if _, ok := t.subscribers[events]; ok {
+			delete(t.subscribers, events)
+			close(events)
+		}
+	}
+	return t.Terminal, append([]byte(nil), t.backlog...), events, detach, nil
+}
+
+func (t *relayTerminal) publish(event Event) {
+	for events := range t.subscribers {
+		select {
+		case events <- event:
+		default:
+			delete(t.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+func (t *relayTerminal) finish(err string) {
+	t.State = StateClosed
+	t.Error = err
+	t.ClosedAt = time.Now()
+	t.input = nil
+	t.publish(Event{Closed: true, ExitCode: t.ExitCode, Error: err})
+	for events := range t.subscribers {
+		delete(t.subscribers, events)
+		close(events)
+	}
+}
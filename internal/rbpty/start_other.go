@@ -0,0 +1,22 @@
+//go:build !unix
+
+package rbpty
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+func start(cmd *exec.Cmd, cols, rows int) (*os.File, error) {
+	return nil, fmt.Errorf("ptys are not supported on %s", runtime.GOOS)
+}
+
+func resize(f *os.File, cols, rows int) error {
+	return fmt.Errorf("ptys are not supported on %s", runtime.GOOS)
+}
+
+func hangup(process *os.Process) {
+	process.Kill()
+}
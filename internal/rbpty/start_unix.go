@@ -0,0 +1,26 @@
+//go:build unix
+
+package rbpty
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/creack/pty"
+)
+
+func start(cmd *exec.Cmd, cols, rows int) (*os.File, error) {
+	return pty.StartWithSize(cmd, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+}
+
+func resize(f *os.File, cols, rows int) error {
+	return pty.Setsize(f, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+}
+
+// hangup sends SIGHUP to the shell's process group, which takes foreground
+// jobs such as editors down with it.
+func hangup(process *os.Process) {
+	syscall.Kill(-process.Pid, syscall.SIGHUP)
+	process.Signal(syscall.SIGHUP)
+}
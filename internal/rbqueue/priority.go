@@ -0,0 +1,211 @@
+package rbqueue
+
+import (
+	"container/heap"
+	"context"
+	"sort"
+	"sync"
+)
+
+// PriorityQueue pops the element that less orders first, and is safe for
+// concurrent use. Elements that compare equal come out in the order they
+// were pushed.
+type PriorityQueue[T any] struct {
+	items   *priorityHeap[T]
+	seq     uint64
+	changed chan struct{}
+	mu      sync.Mutex
+}
+
+func NewPriorityQueue[T any](less func(a, b T) bool) *PriorityQueue[T] {
+	return &PriorityQueue[T]{
+		items:   &priorityHeap[T]{less: less},
+		changed: make(chan struct{}),
+	}
+}
+
+func (q *PriorityQueue[T]) Push(v T) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.seq++
+	heap.Push(q.items, prioritised[T]{value: v, seq: q.seq})
+	q.broadcast()
+}
+
+func (q *PriorityQueue[T]) Peek() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var zero T
+	if q.items.Len() == 0 {
+		return zero, false
+	}
+	return q.items.entries[0].value, true
+}
+
+func (q *PriorityQueue[T]) Pop() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pop()
+}
+
+// PopContext removes the first element, waiting for one to arrive until ctx
+// is done.
+func (q *PriorityQueue[T]) PopContext(ctx context.Context) (T, error) {
+	for {
+		q.mu.Lock()
+		if v, ok := q.pop(); ok {
+			q.mu.Unlock()
+			return v, nil
+		}
+		changed := q.changed
+		q.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// Remove deletes and returns the first element, in priority order, that
+// matches.
+func (q *PriorityQueue[T]) Remove(match func(T) bool) (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var zero T
+	index := q.find(match)
+	if index == -1 {
+		return zero, false
+	}
+
+	entry := heap.Remove(q.items, index).(prioritised[T])
+	q.broadcast()
+	return entry.value, true
+}
+
+// Update replaces the first element, in priority order, that matches with
+// update's result and moves it to its new place. It keeps its original push
+// order, so it still comes out after elements that compare equal and were
+// pushed before it.
+func (q *PriorityQueue[T]) Update(match func(T) bool, update func(T) T) (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var zero T
+	index := q.find(match)
+	if index == -1 {
+		return zero, false
+	}
+
+	v := update(q.items.entries[index].value)
+	q.items.entries[index].value = v
+	heap.Fix(q.items, index)
+	q.broadcast()
+	return v, true
+}
+
+// Items returns a copy of the queued elements in the order they would pop.
+func (q *PriorityQueue[T]) Items() []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	sorted := &priorityHeap[T]{less: q.items.less, entries: append([]prioritised[T](nil), q.items.entries...)}
+	sort.Sort(sorted)
+
+	items := make([]T, len(sorted.entries))
+	for i, entry := range sorted.entries {
+		items[i] = entry.value
+	}
+	return items
+}
+
+// Clear empties the queue and returns how many elements were removed.
+func (q *PriorityQueue[T]) Clear() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n := q.items.Len()
+	q.items.entries = nil
+	q.broadcast()
+	return n
+}
+
+func (q *PriorityQueue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.items.Len()
+}
+
+func (q *PriorityQueue[T]) IsEmpty() bool {
+	return q.Len() == 0
+}
+
+// find returns the index of the first entry, in priority order, that
+// matches, or -1.
+func (q *PriorityQueue[T]) find(match func(T) bool) int {
+	index := -1
+	for i, entry := range q.items.entries {
+		if match(entry.value) && (index == -1 || q.items.Less(i, index)) {
+			index = i
+		}
+	}
+	return index
+}
+
+func (q *PriorityQueue[T]) pop() (T, bool) {
+	var zero T
+	if q.items.Len() == 0 {
+		return zero, false
+	}
+
+	entry := heap.Pop(q.items).(prioritised[T])
+	q.broadcast()
+	return entry.value, true
+}
+
+func (q *PriorityQueue[T]) broadcast() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+type prioritised[T any] struct {
+	value T
+	seq   uint64
+}
+
+// priorityHeap implements heap.Interface, breaking ties by push order.
+type priorityHeap[T any] struct {
+	entries []prioritised[T]
+	less    func(a, b T) bool
+}
+
+func (h *priorityHeap[T]) Len() int { return len(h.entries) }
+
+func (h *priorityHeap[T]) Less(i, j int) bool {
+	a, b := h.entries[i], h.entries[j]
+	if h.less(a.value, b.value) {
+		return true
+	}
+	if h.less(b.value, a.value) {
+		return false
+	}
+	return a.seq < b.seq
+}
+
+func (h *priorityHeap[T]) Swap(i, j int) { h.entries[i], h.entries[j] = h.entries[j], h.entries[i] }
+
+func (h *priorityHeap[T]) Push(x any) { h.entries = append(h.entries, x.(prioritised[T])) }
+
+func (h *priorityHeap[T]) Pop() any {
+	var zero prioritised[T]
+	n := len(h.entries)
+	entry := h.entries[n-1]
+	h.entries[n-1] = zero
+	h.entries = h.entries[:n-1]
+	return entry
+}
@@ -0,0 +1,229 @@
+package rbqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type job struct {
+	name     string
+	priority int
+}
+
+func byPriority(a, b job) bool {
+	return a.priority > b.priority
+}
+
+func names(jobs []job) []string {
+	result := make([]string, 0, len(jobs))
+	for _, j := range jobs {
+		result = append(result, j.name)
+	}
+	return result
+}
+
+func popAll(q *PriorityQueue[job]) []string {
+	result := make([]string, 0)
+	for {
+		j, ok := q.Pop()
+		if !ok {
+			return result
+		}
+		result = append(result, j.name)
+	}
+}
+
+func TestPriorityQueueOrder(t *testing.T) {
+	q := NewPriorityQueue(byPriority)
+	q.Push(job{"low", 0})
+	q.Push(job{"high", 10})
+	q.Push(job{"mid", 5})
+	q.Push(job{"negative", -1})
+
+	if j, ok := q.Peek(); !ok || j.name != "high" {
+		t.Fatalf("Peek() = %v, %v, want high", j, ok)
+	}
+	want := []string{"high", "mid", "low", "negative"}
+	if got := names(q.Items()); !slices.Equal(got, want) {
+		t.Fatalf("Items() = %v, want %v", got, want)
+	}
+	if got := popAll(q); !slices.Equal(got, want) {
+		t.Fatalf("popped %v, want %v", got, want)
+	}
+}
+
+func TestPriorityQueueStableTies(t *testing.T) {
+	q := NewPriorityQueue(byPriority)
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		q.Push(job{name, 1})
+	}
+	q.Push(job{"urgent", 2})
+	q.Push(job{"later", 0})
+
+	want := []string{"urgent", "a", "b", "c", "d", "e", "f", "g", "h", "later"}
+	if got := names(q.Items()); !slices.Equal(got, want) {
+		t.Fatalf("Items() = %v, want %v", got, want)
+	}
+	if got := popAll(q); !slices.Equal(got, want) {
+		t.Fatalf("popped %v, want %v", got, want)
+	}
+}
+
+func setPriority(q *PriorityQueue[job], name string, priority int) bool {
+	_, ok := q.Update(
+		func(j job) bool { return j.name == name },
+		func(j job) job {
+			j.priority = priority
+			return j
+		},
+	)
+	return ok
+}
+
+func TestPriorityQueueSetPriority(t *testing.T) {
+	q := NewPriorityQueue(byPriority)
+	q.Push(job{"a", 1})
+	q.Push(job{"b", 0})
+	q.Push(job{"c", 1})
+	q.Push(job{"d", 0})
+
+	// Raised to the priority of older tasks, b stays behind a but keeps its
+	// place ahead of c, which was pushed after it
+	if !setPriority(q, "b", 1) {
+		t.Fatal("Update() did not find b")
+	}
+	if got, want := names(q.Items()), []string{"a", "b", "c", "d"}; !slices.Equal(got, want) {
+		t.Fatalf("after raising b: %v, want %v", got, want)
+	}
+
+	// Lowered, a goes behind d rather than ahead of it
+	if !setPriority(q, "a", 0) {
+		t.Fatal("Update() did not find a")
+	}
+	if got, want := names(q.Items()), []string{"b", "c", "a", "d"}; !slices.Equal(got, want) {
+		t.Fatalf("after lowering a: %v, want %v", got, want)
+	}
+
+	if !setPriority(q, "d", 5) {
+		t.Fatal("Update() did not find d")
+	}
+	if setPriority(q, "missing", 5) {
+		t.Fatal("Update() found a job that was never pushed")
+	}
+	if got, want := popAll(q), []string{"d", "b", "c", "a"}; !slices.Equal(got, want) {
+		t.Fatalf("popped %v, want %v", got, want)
+	}
+}
+
+func TestPriorityQueueRemoveAndClear(t *testing.T) {
+	q := NewPriorityQueue(byPriority)
+	q.Push(job{"a", 0})
+	q.Push(job{"b", 3})
+	q.Push(job{"c", 3})
+
+	if j, ok := q.Remove(func(j job) bool { return j.priority == 3 }); !ok || j.name != "b" {
+		t.Fatalf("Remove() = %v, %v, want b", j, ok)
+	}
+	if got, want := names(q.Items()), []string{"c", "a"}; !slices.Equal(got, want) {
+		t.Fatalf("Items() = %v, want %v", got, want)
+	}
+	if n := q.Clear(); n != 2 {
+		t.Fatalf("Clear() = %d, want 2", n)
+	}
+	if !q.IsEmpty() {
+		t.Fatal("queue not empty after Clear()")
+	}
+}
+
+func TestPriorityQueuePopContext(t *testing.T) {
+	q := NewPriorityQueue(byPriority)
+
+	done := make(chan job, 1)
+	go func() {
+		j, err := q.PopContext(context.Background())
+		if err != nil {
+			t.Errorf("PopContext() = %v", err)
+		}
+		done <- j
+	}()
+	time.Sleep(20 * time.Millisecond)
+	q.Push(job{"a", 0})
+	select {
+	case j := <-done:
+		if j.name != "a" {
+			t.Fatalf("PopContext() = %v, want a", j)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("PopContext() still blocked after a push")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := q.PopContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("PopContext() = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+// TestPriorityQueueConcurrent pushes, reprioritises and pops from several
+// goroutines at once and checks nothing is lost or popped twice.
+func TestPriorityQueueConcurrent(t *testing.T) {
+	const producers, consumers, perProducer = 4, 4, 500
+	q := NewPriorityQueue(byPriority)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var produced sync.WaitGroup
+	for p := range producers {
+		produced.Add(1)
+		go func() {
+			defer produced.Done()
+			for i := range perProducer {
+				name := fmt.Sprintf("%d-%d", p, i)
+				q.Push(job{name, i % 7})
+				if i%3 == 0 {
+					setPriority(q, name, i%5)
+				}
+			}
+		}()
+	}
+
+	received := make(chan string, producers*perProducer)
+	var consumed sync.WaitGroup
+	for range consumers {
+		consumed.Add(1)
+		go func() {
+			defer consumed.Done()
+			for {
+				j, err := q.PopContext(ctx)
+				if err != nil {
+					return
+				}
+				received <- j.name
+			}
+		}()
+	}
+
+	produced.Wait()
+	for len(received) < producers*perProducer {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	consumed.Wait()
+	close(received)
+
+	seen := make(map[string]bool)
+	for name := range received {
+		if seen[name] {
+			t.Fatalf("%s popped twice", name)
+		}
+		seen[name] = true
+	}
+	if len(seen) != producers*perProducer {
+		t.Fatalf("popped %d jobs, want %d", len(seen), producers*perProducer)
+	}
+}
@@ -1,45 +1,187 @@
 package rbqueue
 
-import "sync"
+import (
+	"context"
+	"errors"
+	"sync"
+)
 
+var ErrFull = errors.New("queue is full")
+
+// Queue is a FIFO queue that is safe for concurrent use. A bounded queue
+// (see NewBoundedQueue) refuses or blocks appends once it holds capacity
+// elements.
 type Queue[T any] struct {
-	data []T
-	sync.Mutex
+	data     []T
+	capacity int
+	// changed is closed and replaced whenever the queue changes, waking any
+	// blocked PopContext or AppendContext calls
+	changed chan struct{}
+	mu      sync.Mutex
 }
 
 func NewQueue[T any]() *Queue[T] {
 	return &Queue[T]{
-		data: make([]T, 0),
+		data:    make([]T, 0),
+		changed: make(chan struct{}),
 	}
 }
 
-func (q *Queue[T]) Append(v T) {
+// NewBoundedQueue returns a queue that holds at most capacity elements.
+func NewBoundedQueue[T any](capacity int) *Queue[T] {
+	q := NewQueue[T]()
+	q.capacity = capacity
+	return q
+}
+
+// Append adds v to the back of the queue. It returns false without adding v
+// if the queue is bounded and full.
+func (q *Queue[T]) Append(v T) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.full() {
+		return false
+	}
 	q.data = append(q.data, v)
+	q.broadcast()
+	return true
+}
+
+// AppendContext adds v to the back of the queue, waiting for room if the
+// queue is full until ctx is done.
+func (q *Queue[T]) AppendContext(ctx context.Context, v T) error {
+	for {
+		q.mu.Lock()
+		if !q.full() {
+			q.data = append(q.data, v)
+			q.broadcast()
+			q.mu.Unlock()
+			return nil
+		}
+		changed := q.changed
+		q.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
 }
 
 func (q *Queue[T]) Peek() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
 	var zero T
-	if q.IsEmpty() {
+	if len(q.data) == 0 {
 		return zero, false
 	}
 	return q.data[0], true
 }
 
 func (q *Queue[T]) Pop() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pop()
+}
+
+// PopContext removes the front element, waiting for one to arrive until ctx
+// is done.
+func (q *Queue[T]) PopContext(ctx context.Context) (T, error) {
+	for {
+		q.mu.Lock()
+		if v, ok := q.pop(); ok {
+			q.mu.Unlock()
+			return v, nil
+		}
+		changed := q.changed
+		q.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// Remove deletes and returns the first element that matches.
+func (q *Queue[T]) Remove(match func(T) bool) (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
 	var zero T
-	if q.IsEmpty() {
-		return zero, false
+	for i, v := range q.data {
+		if match(v) {
+			copy(q.data[i:], q.data[i+1:])
+			q.data[len(q.data)-1] = zero
+			q.data = q.data[:len(q.data)-1]
+			q.broadcast()
+			return v, true
+		}
 	}
+	return zero, false
+}
 
-	v := q.data[0]
-	q.data = q.data[1:]
-	return v, true
+// Items returns a copy of the queued elements in order.
+func (q *Queue[T]) Items() []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	items := make([]T, len(q.data))
+	copy(items, q.data)
+	return items
+}
+
+// Clear empties the queue and returns how many elements were removed.
+func (q *Queue[T]) Clear() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n := len(q.data)
+	q.data = make([]T, 0)
+	q.broadcast()
+	return n
 }
 
 func (q *Queue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
 	return len(q.data)
 }
 
 func (q *Queue[T]) IsEmpty() bool {
-	return len(q.data) == 0
+	return q.Len() == 0
+}
+
+func (q *Queue[T]) full() bool {
+	return q.capacity > 0 && len(q.data) >= q.capacity
+}
+
+func (q *Queue[T]) pop() (T, bool) {
+	var zero T
+	if len(q.data) == 0 {
+		return zero, false
+	}
+
+	v := q.data[0]
+	// Clear the slot so the backing array doesn't keep the element alive
+	q.data[0] = zero
+	q.data = q.data[1:]
+
+	// Reslicing only moves the start of the slice forward, so copy into a
+	// fresh array once most of the old one is dead space
+	if cap(q.data) > 64 && len(q.data) < cap(q.data)/4 {
+		q.data = append(make([]T, 0, len(q.data)*2), q.data...)
+	}
+	q.broadcast()
+	return v, true
+}
+
+func (q *Queue[T]) broadcast() {
+	close(q.changed)
+	q.changed = make(chan struct{})
 }
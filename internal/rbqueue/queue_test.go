@@ -0,0 +1,231 @@
+package rbqueue
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueueFIFO(t *testing.T) {
+	q := NewQueue[int]()
+	for i := range 5 {
+		q.Append(i)
+	}
+	if got := q.Len(); got != 5 {
+		t.Fatalf("Len() = %d, want 5", got)
+	}
+	if v, ok := q.Peek(); !ok || v != 0 {
+		t.Fatalf("Peek() = %d, %v, want 0, true", v, ok)
+	}
+
+	for i := range 5 {
+		v, ok := q.Pop()
+		if !ok || v != i {
+			t.Fatalf("Pop() = %d, %v, want %d, true", v, ok, i)
+		}
+	}
+	if _, ok := q.Pop(); ok {
+		t.Fatal("Pop() on an empty queue succeeded")
+	}
+	if !q.IsEmpty() {
+		t.Fatal("IsEmpty() = false after popping everything")
+	}
+}
+
+func TestQueueRemoveAndClear(t *testing.T) {
+	q := NewQueue[int]()
+	for i := range 5 {
+		q.Append(i)
+	}
+
+	if v, ok := q.Remove(func(v int) bool { return v%2 == 1 }); !ok || v != 1 {
+		t.Fatalf("Remove() = %d, %v, want 1, true", v, ok)
+	}
+	if _, ok := q.Remove(func(v int) bool { return v > 10 }); ok {
+		t.Fatal("Remove() matched nothing but succeeded")
+	}
+	if got, want := q.Items(), []int{0, 2, 3, 4}; !slices.Equal(got, want) {
+		t.Fatalf("Items() = %v, want %v", got, want)
+	}
+
+	if n := q.Clear(); n != 4 {
+		t.Fatalf("Clear() = %d, want 4", n)
+	}
+	if !q.IsEmpty() {
+		t.Fatal("queue not empty after Clear()")
+	}
+}
+
+func TestBoundedQueueAppend(t *testing.T) {
+	q := NewBoundedQueue[int](2)
+	if !q.Append(1) || !q.Append(2) {
+		t.Fatal("Append() refused below capacity")
+	}
+	if q.Append(3) {
+		t.Fatal("Append() accepted past capacity")
+	}
+
+	q.Pop()
+	if !q.Append(3) {
+		t.Fatal("Append() refused after making room")
+	}
+	if got, want := q.Items(), []int{2, 3}; !slices.Equal(got, want) {
+		t.Fatalf("Items() = %v, want %v", got, want)
+	}
+}
+
+func TestBoundedQueueAppendContextWaitsForRoom(t *testing.T) {
+	q := NewBoundedQueue[int](1)
+	q.Append(1)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- q.AppendContext(context.Background(), 2)
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("AppendContext() returned %v on a full queue", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if v, _ := q.Pop(); v != 1 {
+		t.Fatalf("Pop() = %d, want 1", v)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("AppendContext() = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("AppendContext() still blocked after room was made")
+	}
+	if got, want := q.Items(), []int{2}; !slices.Equal(got, want) {
+		t.Fatalf("Items() = %v, want %v", got, want)
+	}
+}
+
+func TestBoundedQueueAppendContextDeadline(t *testing.T) {
+	q := NewBoundedQueue[int](1)
+	q.Append(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := q.AppendContext(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("AppendContext() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if got := q.Len(); got != 1 {
+		t.Fatalf("Len() = %d after a failed append, want 1", got)
+	}
+}
+
+func TestQueuePopContextWaitsForAppend(t *testing.T) {
+	q := NewQueue[int]()
+
+	done := make(chan int, 1)
+	go func() {
+		v, err := q.PopContext(context.Background())
+		if err != nil {
+			t.Errorf("PopContext() = %v", err)
+		}
+		done <- v
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	q.Append(7)
+	select {
+	case v := <-done:
+		if v != 7 {
+			t.Fatalf("PopContext() = %d, want 7", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("PopContext() still blocked after an append")
+	}
+}
+
+func TestQueuePopContextCancel(t *testing.T) {
+	q := NewQueue[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := q.PopContext(ctx)
+		done <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("PopContext() = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("PopContext() still blocked after cancel")
+	}
+}
+
+// TestQueueConcurrent runs producers and consumers against a small bounded
+// queue, so both sides spend time blocked, and checks every element arrives
+// exactly once.
+func TestQueueConcurrent(t *testing.T) {
+	const producers, consumers, perProducer = 4, 4, 500
+	q := NewBoundedQueue[[2]int](8)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var produced sync.WaitGroup
+	for p := range producers {
+		produced.Add(1)
+		go func() {
+			defer produced.Done()
+			for i := range perProducer {
+				if err := q.AppendContext(ctx, [2]int{p, i}); err != nil {
+					t.Errorf("AppendContext() = %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	received := make(chan [2]int, producers*perProducer)
+	var consumed sync.WaitGroup
+	for range consumers {
+		consumed.Add(1)
+		go func() {
+			defer consumed.Done()
+			for {
+				v, err := q.PopContext(ctx)
+				if err != nil {
+					return
+				}
+				received <- v
+			}
+		}()
+	}
+
+	produced.Wait()
+	for len(received) < producers*perProducer {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	consumed.Wait()
+	close(received)
+
+	seen := make(map[[2]int]bool)
+	for v := range received {
+		if seen[v] {
+			t.Fatalf("element %v popped twice", v)
+		}
+		seen[v] = true
+	}
+	if len(seen) != producers*perProducer {
+		t.Fatalf("popped %d elements, want %d", len(seen), producers*perProducer)
+	}
+	if !q.IsEmpty() {
+		t.Fatalf("queue still holds %d elements", q.Len())
+	}
+}
@@ -0,0 +1,94 @@
+package rbresult
+
+import "time"
+
+// File is a directory entry in the structured results of the beacon's
+// filesystem commands. Output parsed on the server from ls -l may lack a
+// path or a modification time.
+type File struct {
+	Name       string    `json:"name"`
+	Path       string    `json:"path,omitempty"`
+	Type       string    `json:"type"`
+	Mode       string    `json:"mode"`
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"modTime,omitzero"`
+	Owner      string    `json:"owner,omitempty"`
+	Group      string    `json:"group,omitempty"`
+	LinkTarget string    `json:"linkTarget,omitempty"`
+}
+
+// Listing is the structured result of ls -json and find -json.
+type Listing struct {
+	Path      string `json:"path,omitempty"`
+	Entries   []File `json:"entries"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// Process is a running process in the structured results of ps and pstree.
+// Output parsed from other programs' ps may only have the start time as the
+// program printed it, in Started, and may add CPU and memory use.
+type Process struct {
+	PID       int       `json:"pid"`
+	PPID      int       `json:"ppid"`
+	User      string    `json:"user,omitempty"`
+	Name      string    `json:"name"`
+	State     string    `json:"state,omitempty"`
+	StartTime time.Time `json:"startTime,omitzero"`
+	Started   string    `json:"started,omitempty"`
+	CPU       float64   `json:"cpu,omitempty"`
+	Memory    float64   `json:"memory,omitempty"`
+	Command   string    `json:"command"`
+}
+
+// Socket is a TCP or UDP socket in the structured result of netstat -json.
+// PID and Process are only set for sockets owned by processes the beacon can
+// inspect.
+type Socket struct {
+	Proto   string `json:"proto"`
+	Local   string `json:"local"`
+	Remote  string `json:"remote"`
+	State   string `json:"state,omitempty"`
+	User    string `json:"user,omitempty"`
+	PID     int    `json:"pid,omitempty"`
+	Process string `json:"process,omitempty"`
+}
+
+// Route is a routing table entry in the structured result of
+// netstat -r -json. Mask is only known for netstat output parsed on the
+// server; the beacon gives destinations in CIDR form.
+type Route struct {
+	Destination string `json:"destination"`
+	Gateway     string `json:"gateway,omitempty"`
+	Mask        string `json:"mask,omitempty"`
+	Interface   string `json:"interface"`
+	Metric      int    `json:"metric"`
+}
+
+// Interface is a network interface in the structured result of
+// ifconfig -json. Addresses are in CIDR form where the netmask is known.
+type Interface struct {
+	Name      string   `json:"name"`
+	Index     int      `json:"index"`
+	MTU       int      `json:"mtu"`
+	MAC       string   `json:"mac,omitempty"`
+	Flags     []string `json:"flags"`
+	Addresses []string `json:"addresses"`
+}
+
+// Identity is a user and their groups in the structured results of whoami
+// and id. whoami output parsed on the server only has the user.
+type Identity struct {
+	User   string  `json:"user"`
+	UID    string  `json:"uid"`
+	GID    string  `json:"gid,omitempty"`
+	EUID   string  `json:"euid,omitempty"`
+	EGID   string  `json:"egid,omitempty"`
+	Groups []Group `json:"groups"`
+	// Capabilities are the effective Linux capabilities, by name
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+type Group struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
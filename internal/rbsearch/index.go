@@ -0,0 +1,254 @@
+package rbsearch
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	KindResponse = "response"
+	KindFile     = "file"
+)
+
+// snippetContext is how many bytes of text either side of a match a snippet
+// shows.
+const snippetContext = 60
+
+// Document is a piece of text to index, along with where it came from.
+type Document struct {
+	ID        string `json:"id"`
+	Kind      string `json:"kind"`
+	SessionID string `json:"sessionId,omitempty"`
+	TaskID    string `json:"taskId,omitempty"`
+	// Title is the command for responses and the filename for files
+	Title string    `json:"title"`
+	Time  time.Time `json:"time"`
+	Text  string    `json:"-"`
+}
+
+// Hit is a document matching a search, with snippets of text around the
+// matches.
+type Hit struct {
+	Document
+	Score    int      `json:"score"`
+	Snippets []string `json:"snippets"`
+}
+
+// Query selects documents containing every term of Text. Empty fields other
+// than Text match everything.
+type Query struct {
+	Text      string
+	SessionID string
+	Kind      string
+	Limit     int
+}
+
+// Index is an in-memory inverted index from lower-cased terms to the
+// documents containing them.
+type Index struct {
+	docs     map[string]Document
+	postings map[string]map[string]int
+	sync.Mutex
+}
+
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[string]Document),
+		postings: make(map[string]map[string]int),
+	}
+}
+
+// Add indexes a document, replacing any earlier document with the same ID.
+func (i *Index) Add(doc Document) {
+	i.Lock()
+	defer i.Unlock()
+
+	if _, ok := i.docs[doc.ID]; ok {
+		i.remove(doc.ID)
+	}
+	i.docs[doc.ID] = doc
+	for _, term := range Tokenize(doc.Text) {
+		docs, ok := i.postings[term]
+		if !ok {
+			docs = make(map[string]int)
+			i.postings[term] = docs
+		}
+		docs[doc.ID]++
+	}
+}
+
+func (i *Index) Remove(id string) {
+	i.Lock()
+	defer i.Unlock()
+	i.remove(id)
+}
+
+func (i *Index) Len() int {
+	i.Lock()
+	defer i.Unlock()
+	return len(i.docs)
+}
+
+// Search returns the documents matching q, those with the most occurrences of
+// the terms first and newest first among equals.
+func (i *Index) Search(q Query) []Hit {
+	terms := unique(Tokenize(q.Text))
+	if len(terms) == 0 {
+		return []Hit{}
+	}
+
+	i.Lock()
+	defer i.Unlock()
+
+	// Start from the rarest term so the candidate set is as small as it gets
+	sort.Slice(terms, func(a, b int) bool { return len(i.postings[terms[a]]) < len(i.postings[terms[b]]) })
+
+	hits := make([]Hit, 0)
+	for id, count := range i.postings[terms[0]] {
+		score := count
+		for _, term := range terms[1:] {
+			n, ok := i.postings[term][id]
+			if !ok {
+				score = 0
+				break
+			}
+			score += n
+		}
+		if score == 0 {
+			continue
+		}
+
+		doc := i.docs[id]
+		if q.SessionID != "" && doc.SessionID != q.SessionID {
+			continue
+		}
+		if q.Kind != "" && doc.Kind != q.Kind {
+			continue
+		}
+		hits = append(hits, Hit{Document: doc, Score: score})
+	}
+
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].Score != hits[b].Score {
+			return hits[a].Score > hits[b].Score
+		}
+		return hits[a].Time.After(hits[b].Time)
+	})
+	if q.Limit > 0 && len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+
+	// Only build snippets for the hits actually returned
+	for n := range hits {
+		hits[n].Snippets = snippets(hits[n].Text, terms, 3)
+	}
+	return hits
+}
+
+func (i *Index) remove(id string) {
+	doc, ok := i.docs[id]
+	if !ok {
+		return
+	}
+	for _, term := range Tokenize(doc.Text) {
+		delete(i.postings[term], id)
+		if len(i.postings[term]) == 0 {
+			delete(i.postings, term)
+		}
+	}
+	delete(i.docs, id)
+}
+
+// Tokenize splits text into lower-cased terms. Dots, dashes, underscores and
+// slashes are kept inside terms so paths, hostnames and IP addresses can be
+// searched for whole.
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("._-/", r)
+	})
+
+	terms := make([]string, 0, len(fields))
+	for _, field := range fields {
+		field = strings.Trim(field, "._-/")
+		if field != "" {
+			terms = append(terms, field)
+		}
+	}
+	return terms
+}
+
+func unique(terms []string) []string {
+	seen := make(map[string]struct{}, len(terms))
+	out := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if _, ok := seen[term]; !ok {
+			seen[term] = struct{}{}
+			out = append(out, term)
+		}
+	}
+	return out
+}
+
+// snippets returns up to limit non-overlapping excerpts of text around
+// occurrences of the terms, in the order they appear.
+func snippets(text string, terms []string, limit int) []string {
+	lower := strings.ToLower(text)
+
+	type span struct{ start, end int }
+	spans := make([]span, 0)
+	for _, term := range terms {
+		for from := 0; from < len(lower); {
+			n := strings.Index(lower[from:], term)
+			if n == -1 {
+				break
+			}
+			at := from + n
+			// Lower-casing can change byte lengths, so clamp to the original text
+			start := min(max(at-snippetContext, 0), len(text))
+			spans = append(spans, span{start, min(at+len(term)+snippetContext, len(text))})
+			from = at + len(term)
+		}
+	}
+	sort.Slice(spans, func(a, b int) bool { return spans[a].start < spans[b].start })
+
+	out := make([]string, 0, limit)
+	end := -1
+	for _, s := range spans {
+		if len(out) == limit {
+			break
+		}
+		if s.start < end {
+			continue
+		}
+		out = append(out, excerpt(text, s.start, s.end))
+		end = s.end
+	}
+	return out
+}
+
+// excerpt cuts text[start:end] on rune boundaries, collapses whitespace and
+// marks where it was cut.
+func excerpt(text string, start, end int) string {
+	for start > 0 && start < len(text) && !isRuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !isRuneStart(text[end]) {
+		end++
+	}
+
+	s := strings.Join(strings.Fields(text[start:end]), " ")
+	if start > 0 {
+		s = "…" + s
+	}
+	if end < len(text) {
+		s += "…"
+	}
+	return s
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,71 @@
+package rbsession
+
+import "time"
+
+type Health string
+
+const (
+	HealthActive Health = "active"
+	HealthLate   Health = "late"
+	HealthDead   Health = "dead"
+)
+
+// Thresholds decide a session's health from how many check-ins it has missed,
+// measured in multiples of the beacon's own sleep time.
+type Thresholds struct {
+	LateAfter float64
+	DeadAfter float64
+	Grace     time.Duration
+}
+
+// Transition records a session whose health changed during a refresh.
+type Transition struct {
+	Session Session
+	From    Health
+	To      Health
+}
+
+// MissedCheckIns returns how many check-in intervals have passed since the
+// session was last seen, after allowing for the grace period.
+func (s *Session) MissedCheckIns(now time.Time, t Thresholds) float64 {
+	interval := time.Duration(s.SleepTime) * time.Second
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	overdue := now.Sub(s.LastCheckIn) - t.Grace
+	if overdue <= 0 {
+		return 0
+	}
+	return float64(overdue) / float64(interval)
+}
+
+func (s *Session) healthAt(now time.Time, t Thresholds) Health {
+	missed := s.MissedCheckIns(now, t)
+	switch {
+	case missed >= t.DeadAfter:
+		return HealthDead
+	case missed >= t.LateAfter:
+		return HealthLate
+	default:
+		return HealthActive
+	}
+}
+
+// RefreshHealth recomputes the health of every session and returns the ones
+// that changed state.
+func (s *Store) RefreshHealth(now time.Time, t Thresholds) []Transition {
+	s.Lock()
+	defer s.Unlock()
+
+	transitions := make([]Transition, 0)
+	for _, sess := range s.sessions {
+		health := sess.healthAt(now, t)
+		if health == sess.Health {
+			continue
+		}
+		transitions = append(transitions, Transition{Session: *sess, From: sess.Health, To: health})
+		sess.Health = health
+	}
+	return transitions
+}
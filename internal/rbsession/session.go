@@ -0,0 +1,128 @@
+package rbsession
+
+import (
+	"redbull/internal/rbhost"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Session is a single beacon known to the server, keyed by the ID the beacon
+// generated for itself at startup.
+type Session struct {
+	ID          string      `json:"id"`
+	Host        rbhost.Info `json:"host"`
+	RemoteAddr  string      `json:"remoteAddr"`
+	SleepTime   int         `json:"sleepTime"`
+	Health      Health      `json:"health"`
+	FirstSeen   time.Time   `json:"firstSeen"`
+	LastCheckIn time.Time   `json:"lastCheckIn"`
+
+	Tasks *Tasks `json:"-"`
+}
+
+type Store struct {
+	sessions map[string]*Session
+	sync.Mutex
+}
+
+func NewStore() *Store {
+	return &Store{
+		sessions: make(map[string]*Session),
+	}
+}
+
+// Register creates the session if it is new, or refreshes its host profile if
+// the beacon is re-registering (for example after a server restart). The
+// returned bool reports whether the session was newly created.
+func (s *Store) Register(id string, host rbhost.Info, sleepTime int, remoteAddr string) (Session, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	now := time.Now()
+	sess, ok := s.sessions[id]
+	if !ok {
+		sess = &Session{
+			ID:        id,
+			Health:    HealthActive,
+			FirstSeen: now,
+			Tasks:     NewTasks(),
+		}
+		s.sessions[id] = sess
+	}
+
+	sess.Host = host
+	sess.SleepTime = sleepTime
+	sess.RemoteAddr = remoteAddr
+	sess.LastCheckIn = now
+	return *sess, !ok
+}
+
+// CheckIn records a check-in for the session and returns it, or false if the
+// session has never registered. A negative sleepTime leaves the expected
+// interval unchanged.
+func (s *Store) CheckIn(id string, sleepTime int, remoteAddr string) (*Session, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	sess, ok := s.sessions[id]
+	if !ok {
+		return nil, false
+	}
+	sess.LastCheckIn = time.Now()
+	sess.RemoteAddr = remoteAddr
+	if sleepTime >= 0 {
+		sess.SleepTime = sleepTime
+	}
+	return sess, true
+}
+
+func (s *Store) Get(id string) (*Session, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	sess, ok := s.sessions[id]
+	return sess, ok
+}
+
+// Snapshot returns a copy of the session that is safe to serialise.
+func (s *Store) Snapshot(id string) (Session, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	sess, ok := s.sessions[id]
+	if !ok {
+		return Session{}, false
+	}
+	return *sess, true
+}
+
+// List returns copies of all sessions, most recently seen first.
+func (s *Store) List() []Session {
+	s.Lock()
+	defer s.Unlock()
+
+	list := make([]Session, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		list = append(list, *sess)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].LastCheckIn.After(list[j].LastCheckIn)
+	})
+	return list
+}
+
+// Latest returns the most recently seen session. It is used when an operator
+// request does not name a session, which keeps single-beacon setups working.
+func (s *Store) Latest() (*Session, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	var latest *Session
+	for _, sess := range s.sessions {
+		if latest == nil || sess.LastCheckIn.After(latest.LastCheckIn) {
+			latest = sess
+		}
+	}
+	return latest, latest != nil
+}
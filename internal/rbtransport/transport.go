@@ -0,0 +1,45 @@
+package rbtransport
+
+import (
+	"errors"
+	"io"
+	"redbull/internal/rbhttp"
+)
+
+var (
+	// ErrUnknownSession means the server has no record of the session, usually
+	// because it restarted; the beacon should register again.
+	ErrUnknownSession = errors.New("unknown session")
+	ErrNotFound       = errors.New("not found")
+)
+
+// Transport is the beacon's side of a channel to the server. Command code only
+// talks to the server through this interface, so new channels can be added
+// without touching it.
+type Transport interface {
+	Register(req rbhttp.RegisterRequest) error
+	// CheckIn returns the next batch of tasks, or nil when the server has
+	// nothing queued for the session.
+	CheckIn(sessionID string, sleepTime int) (*rbhttp.CheckInResponse, error)
+	SendResults(results []rbhttp.HttpBody) error
+	// SendFile uploads a file from the beacon and returns the name the server
+	// stored it under.
+	SendFile(sessionID string, body io.Reader) (string, error)
+	FetchFile(name string) (io.ReadCloser, error)
+	// ExchangePty sends terminal output and returns any input waiting for the
+	// session's terminals.
+	ExchangePty(sessionID string, output []rbhttp.PtyOutput) ([]rbhttp.PtyInput, error)
+}
+
+// Handler is the server's side of a channel. Each server backend decodes its
+// wire format and calls into the Handler, which owns sessions and queues.
+type Handler interface {
+	Register(req rbhttp.RegisterRequest, remoteAddr string) (rbhttp.RegisterResponse, error)
+	// CheckIn returns nil when there is nothing queued for the session.
+	CheckIn(sessionID string, sleepTime int, remoteAddr string) (*rbhttp.CheckInResponse, error)
+	Results(results []rbhttp.HttpBody) error
+	ReceiveFile(sessionID string, body io.Reader) (string, error)
+	// ServeFile opens a staged file for the beacon along with its size.
+	ServeFile(name string) (io.ReadCloser, int64, error)
+	ExchangePty(sessionID string, output []rbhttp.PtyOutput) ([]rbhttp.PtyInput, error)
+}
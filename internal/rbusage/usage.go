@@ -0,0 +1,39 @@
+package rbusage
+
+// Commands maps each beacon command to a line saying what it does and how to
+// call it. It is kept apart from the commands so that clients such as rbctl
+// can show help without linking the beacon's code.
+var Commands = map[string]string{
+	"shell":        "Execute a shell command: shell <command>",
+	"shellsession": "Run a command in a shell that persists between tasks, keeping exports, functions and cd: shellsession <command> | shellsession -status [-json] | -reset | -close",
+	"pwd":          "Print the current working directory: pwd",
+	"cd":           "Change directory: cd <path>",
+	"ls":           "List directory contents: ls [-l] [-R] [-depth n] [-name glob] [-sort name|size|time] [-r] [-limit n] [-json] [path]",
+	"status":       "Display beacon status information: status",
+	"sleep":        "Set the sleep time between check-ins (in seconds): sleep <seconds>",
+	"help":         "Display help information: help",
+	"download":     "Download a file from this computer: download <filename>, or a directory as an archive: download -r [-format tar.gz|zip] [-include glob] [-exclude glob] [-max-file-size n[K|M|G]] [-max-total-size n[K|M|G]] <dir>",
+	"upload":       "Upload a file from the server: upload <filename> <desired-filename>",
+	"upstream":     "Manage upstreams: upstream [list | add <url> [proxy-url] [--krb] | set <index> <url> [proxy-url] [--krb] | use <index> | remove <index>]",
+	"cat":          "Print a file: cat [-offset n] [-length n] [-head lines] [-tail lines] [-json] <path>",
+	"mkdir":        "Create directories: mkdir [-p] [-json] <path>...",
+	"rm":           "Remove files: rm [-r] [-f] [-json] <path>...",
+	"mv":           "Move or rename files: mv [-json] <source>... <destination>",
+	"cp":           "Copy files: cp [-r] [-json] <source>... <destination>",
+	"stat":         "Describe files: stat [-L] [-json] <path>...",
+	"find":         "Find files below a directory: find [-name glob] [-type f|d|l] [-min-size n[K|M|G]] [-max-size n[K|M|G]] [-newer age] [-older age] [-maxdepth n] [-limit n] [-l] [-json] [path]",
+	"hash":         "Hash files: hash [-algo md5|sha1|sha256] [-json] <path>...",
+	"touch":        "Create files or set their times: touch [-c] [-time RFC3339] [-json] <path>...",
+	"ps":           "List processes: ps [-user name] [-name glob] [-sort pid|start|name] [-json]",
+	"kill":         "Signal a process: kill [-json] <pid> [signal], where signal is a name like TERM or KILL or a number (default TERM, or KILL where that is the only signal)",
+	"pstree":       "Show processes as a tree: pstree [-json] [pid]",
+	"env":          "Show or change the beacon's environment, which shell tasks inherit: env [-json] [get <name> | set <name>=<value> | unset <name>]",
+	"whoami":       "Show the user the beacon runs as: whoami [-json]",
+	"id":           "Show the beacon's user, groups and, on Linux, effective capabilities: id [-json]",
+	"hostinfo":     "Describe the host's OS release, kernel, uptime, CPUs and memory: hostinfo [-json]",
+	"ifconfig":     "List network interfaces and their addresses: ifconfig [-json] [name]",
+	"netstat":      "List sockets, or routes with -r: netstat [-l] [-proto tcp|udp] [-r] [-json]",
+	"resolve":      "Resolve a host name with the host's resolver, or an IP back to names: resolve [-timeout 5s] [-json] <name>",
+	"tcpcheck":     "Check that a TCP connection can be made: tcpcheck [-timeout 5s] [-json] <host:port>",
+	"pty":          "Manage interactive terminals, attached to through the server: pty [-json] [list | open [-id <id>] [-cols 80] [-rows 24] [shell] | close <id>]",
+}